@@ -0,0 +1,24 @@
+package main
+
+import (
+	"flag"
+	"strings"
+)
+
+func runConvert(flags *flag.FlagSet, args []string) error {
+	format := flags.String("to", "", "output `format`: "+strings.Join(formats, ", ")+" (default from the output file name, else binary)")
+	output := flags.String("o", "", "output `file` (default standard output)")
+	args, err := parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	if *format == "" {
+		*format = guessFormat(*output)
+	}
+
+	net, err := loadNet(args[0])
+	if err != nil {
+		return err
+	}
+	return writeNet(*output, net, *format)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+
+	"github.com/cacilhas/neuron/neuron"
+)
+
+func runDiff(flags *flag.FlagSet, args []string) error {
+	behaviour := flags.Bool("behaviour", false, "also compare actions over random readings")
+	var options neuron.BehaviourOptions
+	flags.IntVar(&options.Count, "samples", 1000, "`number` of random readings")
+	seed := flags.Int64("seed", 1, "random `seed` of the readings")
+	args, err := parse(flags, args, 2)
+	if err != nil {
+		return err
+	}
+
+	before, err := loadNet(args[0])
+	if err != nil {
+		return err
+	}
+	after, err := loadNet(args[1])
+	if err != nil {
+		return err
+	}
+	fmt.Print(neuron.Diff(before, after))
+	if *behaviour {
+		options.Rand = rand.New(rand.NewSource(*seed))
+		report, err := neuron.DiffBehaviour(before, after, options)
+		if err != nil {
+			return err
+		}
+		fmt.Print(report)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/cacilhas/neuron/neuron"
+)
+
+func runDot(flags *flag.FlagSet, args []string) error {
+	var options neuron.DotOptions
+	flags.IntVar(&options.Threshold, "threshold", 0, "hide edges of absolute gene up to `n`")
+	flags.StringVar(&options.Name, "name", "", "graph `name` (default net)")
+	output := flags.String("o", "", "output `file` (default standard output)")
+	args, err := parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+
+	net, err := loadNet(args[0])
+	if err != nil {
+		return err
+	}
+	out, err := create(*output)
+	if err != nil {
+		return err
+	}
+	if err := neuron.WriteDot(out, net, options); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
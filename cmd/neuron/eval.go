@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"unicode/utf8"
+
+	"github.com/cacilhas/neuron/neuron"
+)
+
+// runEval stream a CSV of readings through a net into a CSV of actions
+func runEval(flags *flag.FlagSet, args []string) error {
+	var options neuron.EvaluateOptions
+	flags.BoolVar(&options.Raw, "raw", false, "write activations rather than true or false")
+	flags.BoolVar(&options.Keep, "keep", false, "copy the input columns ahead of the actions")
+	comma := flags.String("comma", ",", "field `delimiter`")
+	output := flags.String("o", "", "output `file` (default standard output)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		flags.Usage()
+		return fmt.Errorf("wrong number of arguments")
+	}
+	if utf8.RuneCountInString(*comma) != 1 {
+		return fmt.Errorf("delimiter %q is not a single character", *comma)
+	}
+	options.Comma, _ = utf8.DecodeRuneInString(*comma)
+
+	net, err := loadNet(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	var in io.Reader = os.Stdin
+	if path := flags.Arg(1); path != "" && path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		in = file
+	}
+	out, err := create(*output)
+	if err != nil {
+		return err
+	}
+	if _, err := neuron.EvaluateCSV(in, out, net, options); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/cacilhas/neuron/neuron"
+)
+
+func runInspect(flags *flag.FlagSet, args []string) error {
+	args, err := parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	net, err := loadNet(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Print(inspect(net))
+	return nil
+}
+
+// inspect describe the sensors, actions, layers and genes of a net
+func inspect(net neuron.NeuralNet) string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "sensors: %v (%v)\n", len(net.GetSensors()), strings.Join(net.GetSensors(), ", "))
+	fmt.Fprintf(&buf, "actions: %v (%v)\n", len(net.GetActions()), strings.Join(net.GetActions(), ", "))
+
+	var all geneStats
+	frozen := 0
+	fmt.Fprintln(&buf, "layers:")
+	for i := 0; net.GetNeurons(i) != nil; i++ {
+		var stats geneStats
+		for j, neu := range net.GetNeurons(i) {
+			for k := 0; k < neu.GetSize(); k++ {
+				stats.add(neu.GetGene(k))
+				all.add(neu.GetGene(k))
+			}
+			if net.IsFrozen(i, j) {
+				frozen++
+			}
+		}
+		fmt.Fprintf(&buf, "  %v: %v neurons, %v, bounds %v; genes %v\n",
+			i, len(net.GetNeurons(i)), net.GetActivation(i), net.GetBounds(i), stats)
+	}
+	fmt.Fprintf(&buf, "genes: %v\n", all)
+	if frozen > 0 {
+		fmt.Fprintf(&buf, "frozen: %v neurons\n", frozen)
+	}
+
+	metadata := net.GetMetadata()
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&buf, "meta %v: %q\n", key, metadata[key])
+	}
+	return buf.String()
+}
+
+type geneStats struct {
+	count, zero int
+	min, max    int
+	sum, sumAbs float64
+}
+
+func (stats *geneStats) add(gene int) {
+	if stats.count == 0 || gene < stats.min {
+		stats.min = gene
+	}
+	if stats.count == 0 || gene > stats.max {
+		stats.max = gene
+	}
+	stats.count++
+	if gene == 0 {
+		stats.zero++
+	}
+	stats.sum += float64(gene)
+	stats.sumAbs += math.Abs(float64(gene))
+}
+
+func (stats geneStats) String() string {
+	if stats.count == 0 {
+		return "0"
+	}
+	count := float64(stats.count)
+	return fmt.Sprintf("%v in [%v, %v], mean %.4g, mean abs %.4g, %v zero",
+		stats.count, stats.min, stats.max, stats.sum/count, stats.sumAbs/count, stats.zero)
+}
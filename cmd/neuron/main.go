@@ -0,0 +1,204 @@
+// Command neuron inspects and transforms saved neural nets.
+//
+// Usage:
+//
+//	neuron <command> [flags] [arguments]
+//
+// Run "neuron <command> -h" for the flags of a command. Nets are read from
+// the named files, "-" standing for the standard input, in any format:
+// binary, compressed or not, JSON or text.
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cacilhas/neuron/neuron"
+)
+
+type command struct {
+	usage   string
+	summary string
+	run     func(flags *flag.FlagSet, args []string) error
+}
+
+var commands = map[string]command{
+	"convert":  {"[-to format] [-o file] net", "convert a net between binary, JSON and text", runConvert},
+	"diff":     {"[-behaviour] [-samples n] [-seed n] before after", "compare two nets", runDiff},
+	"dot":      {"[-threshold n] [-name name] [-o file] net", "draw a net as a Graphviz digraph", runDot},
+	"eval":     {"[-raw] [-keep] [-comma c] [-o file] net [readings.csv]", "compute the actions of a net for each row of a CSV", runEval},
+	"inspect":  {"net", "describe sensors, actions, layers and genes", runInspect},
+	"mutate":   {"[-dev n] [-seed n] [-n generations] [-id id] [-to format] [-o file] net", "write a child of a net", runMutate},
+	"render":   {"[-format png|svg] [-width n] [-height n] [-threshold n] [-input readings] [-o file] net", "draw a net as a PNG or SVG image", runRender},
+	"validate": {"[-q] net...", "check nets decode and save back", runValidate},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	name := os.Args[1]
+	cmd, ok := commands[name]
+	if !ok {
+		if name != "help" && name != "-h" && name != "-help" {
+			fmt.Fprintf(os.Stderr, "neuron: unknown command %q\n", name)
+		}
+		usage()
+		os.Exit(2)
+	}
+
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: neuron %v %v\n", name, cmd.usage)
+		flags.PrintDefaults()
+	}
+	if err := cmd.run(flags, os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "neuron %v: %v\n", name, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(os.Stderr, "usage: neuron <command> [flags] [arguments]\n\ncommands:")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10v %v\n", name, commands[name].summary)
+	}
+}
+
+// parse parse the flags, expecting the given number of arguments
+func parse(flags *flag.FlagSet, args []string, count int) ([]string, error) {
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() != count {
+		flags.Usage()
+		return nil, fmt.Errorf("wrong number of arguments")
+	}
+	return flags.Args(), nil
+}
+
+// loadNet load a net from a file, or from the standard input for "-"
+func loadNet(path string) (neuron.NeuralNet, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	net, err := decodeNet(data)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", path, err)
+	}
+	return net, nil
+}
+
+// decodeNet decode a net in any format, telling JSON by its opening brace
+// and text by LoadNet not recognising it
+func decodeNet(data []byte) (neuron.NeuralNet, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var value neuron.NetValue
+		if err := json.Unmarshal(trimmed, &value); err != nil {
+			return nil, err
+		}
+		return value.NeuralNet, nil
+	}
+	input := bytes.NewReader(data)
+	net, err := neuron.LoadNet(input)
+	if err == neuron.ErrUnknownFormat {
+		return neuron.ParseNet(string(data))
+	} else if err != nil {
+		return nil, err
+	}
+	if input.Len() > 0 {
+		return nil, fmt.Errorf("%v bytes after the net", input.Len())
+	}
+	return net, nil
+}
+
+// formats are the encodings nets can be written in
+var formats = []string{"binary", "gzip", "legacy", "json", "text"}
+
+// guessFormat return the format a file name suggests, binary by default
+func guessFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gz":
+		return "gzip"
+	case ".json":
+		return "json"
+	case ".txt":
+		return "text"
+	}
+	return "binary"
+}
+
+// writeNet write a net to a file, or to the standard output for "" and "-"
+func writeNet(path string, net neuron.NeuralNet, format string) error {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "binary":
+		err = net.Save(&buf)
+	case "gzip":
+		err = net.SaveCompressed(&buf, gzip.BestCompression)
+	case "legacy":
+		err = net.SaveFormat(&buf, neuron.Legacy)
+	case "json":
+		var data []byte
+		if data, err = json.MarshalIndent(net, "", "  "); err == nil {
+			buf.Write(data)
+			buf.WriteByte('\n')
+		}
+	case "text":
+		buf.WriteString(net.String())
+	default:
+		return fmt.Errorf("unknown format %q, expected one of %v", format, strings.Join(formats, ", "))
+	}
+	if err != nil {
+		return err
+	}
+
+	out, err := create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := out.Write(buf.Bytes()); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// create open the output file, or the standard output for "" and "-"
+func create(path string) (io.WriteCloser, error) {
+	if path == "" || path == "-" {
+		return nopCloser{os.Stdout}, nil
+	}
+	return os.Create(path)
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cacilhas/neuron/neuron"
+)
+
+func testNet(t *testing.T) neuron.NeuralNet {
+	first, _ := neuron.NewNeuron([]int{100, -200})
+	second, _ := neuron.NewNeuron([]int{-300, 400})
+	net, err := neuron.NewNeuralNet([]string{"x", "y"}, []string{"left", "right"}, []neuron.Layer{{first, second}},
+		neuron.WithActivations(neuron.Linear), neuron.WithMetadata(neuron.Metadata{neuron.MetaID: "test"}))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	return net
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "neuron")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	return dir
+}
+
+func TestDecodeNet(t *testing.T) {
+	net := testNet(t)
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	for _, format := range formats {
+		t.Run(format, func(t *testing.T) {
+			path := filepath.Join(dir, format)
+			if err := writeNet(path, net, format); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			decoded, err := decodeNet(data)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if got := decoded.String(); got != net.String() {
+				t.Fatalf("expected\n%v\ngot\n%v", net, got)
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		saved, _ := net.MarshalBinary()
+		for name, data := range map[string][]byte{
+			"garbage":        []byte("not a net"),
+			"trailing bytes": append(saved, 0),
+			"broken JSON":    []byte(`{"sensors": ["x"]`),
+		} {
+			if _, err := decodeNet(data); err == nil {
+				t.Fatalf("%v: expected error not raised", name)
+			}
+		}
+	})
+}
+
+func TestConvertValidate(t *testing.T) {
+	net := testNet(t)
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := func(name string) string { return filepath.Join(dir, name) }
+	run := func(cmd func(*flag.FlagSet, []string) error, args ...string) error {
+		return cmd(flag.NewFlagSet("test", flag.ContinueOnError), args)
+	}
+
+	if err := writeNet(path("net.dna"), net, "binary"); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	// The format follows the output file name unless given
+	if err := run(runConvert, "-o", path("net.txt"), path("net.dna")); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := run(runConvert, "-o", path("net.json"), path("net.txt")); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := run(runConvert, "-to", "gzip", "-o", path("net.out"), path("net.json")); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	for _, name := range []string{"net.txt", "net.json", "net.out"} {
+		loaded, err := loadNet(path(name))
+		if err != nil {
+			t.Fatalf("%v: unexpected error %v", name, err)
+		}
+		if got := loaded.String(); got != net.String() {
+			t.Fatalf("%v: expected\n%v\ngot\n%v", name, net, got)
+		}
+	}
+	if err := run(runConvert, "-to", "yaml", path("net.dna")); err == nil {
+		t.Fatalf("expected error not raised for an unknown format")
+	}
+
+	if err := run(runValidate, "-q", path("net.dna"), path("net.txt"), path("net.json"), path("net.out")); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := ioutil.WriteFile(path("bad.dna"), []byte("not a net"), 0644); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	err := run(runValidate, "-q", path("net.dna"), path("bad.dna"))
+	if err == nil || err.Error() != "1 of 2 files invalid" {
+		t.Fatalf("expected 1 of 2 files invalid, got %v", err)
+	}
+}
+
+func TestMutateFlags(t *testing.T) {
+	for _, args := range [][]string{{"-dev", "0"}, {"-dev", "-5"}, {"-dev", "3000000000"}, {"-n", "-1"}} {
+		// Flags are checked before the net is loaded
+		err := runMutate(flag.NewFlagSet("mutate", flag.ContinueOnError), append(args, "missing.dna"))
+		if err == nil || os.IsNotExist(err) {
+			t.Fatalf("%v: expected a flag error, got %v", args, err)
+		}
+	}
+}
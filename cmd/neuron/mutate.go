@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/cacilhas/neuron/neuron"
+)
+
+func runMutate(flags *flag.FlagSet, args []string) error {
+	dev := flags.Int("dev", 100, "positive gene `deviation`")
+	seed := flags.Int64("seed", 0, "random `seed` (default from the clock)")
+	generations := flags.Int("n", 1, "number of `generations`")
+	id := flags.String("id", "", "`id` metadata of the child")
+	format := flags.String("to", "", "output `format`: "+strings.Join(formats, ", ")+" (default from the output file name, else binary)")
+	output := flags.String("o", "", "output `file` (default standard output)")
+	args, err := parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	// GetChild draws gene changes within a positive int32 deviation
+	if *dev <= 0 || *dev > math.MaxInt32 {
+		return fmt.Errorf("deviation %v out of range [1, %v]", *dev, math.MaxInt32)
+	}
+	if *generations < 0 {
+		return fmt.Errorf("negative number of generations %v", *generations)
+	}
+	if *format == "" {
+		*format = guessFormat(*output)
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+
+	net, err := loadNet(args[0])
+	if err != nil {
+		return err
+	}
+	// GetChild draws from the global source
+	rand.Seed(*seed)
+	for i := 0; i < *generations; i++ {
+		net = net.GetChild(*dev)
+	}
+	if *id != "" {
+		metadata := net.GetMetadata()
+		metadata[neuron.MetaID] = *id
+		if net, err = neuron.Configure(net, neuron.WithMetadata(metadata)); err != nil {
+			return err
+		}
+	}
+	return writeNet(*output, net, *format)
+}
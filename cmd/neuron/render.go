@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cacilhas/neuron/neuron"
+)
+
+func runRender(flags *flag.FlagSet, args []string) error {
+	var options neuron.RenderOptions
+	flags.IntVar(&options.Width, "width", 800, "image width")
+	flags.IntVar(&options.Height, "height", 600, "image height")
+	flags.IntVar(&options.Threshold, "threshold", 0, "hide edges of absolute gene up to `n`")
+	input := flags.String("input", "", "shade neurons by their outputs for `readings` such as x=1,y=-0.5")
+	format := flags.String("format", "", "png or svg (default from the output file name, else svg)")
+	output := flags.String("o", "", "output `file` (default standard output)")
+	args, err := parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+
+	if *format == "" {
+		*format = "svg"
+		if strings.EqualFold(filepath.Ext(*output), ".png") {
+			*format = "png"
+		}
+	}
+	render := neuron.RenderSVG
+	switch *format {
+	case "svg":
+	case "png":
+		render = neuron.RenderPNG
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	if *input != "" {
+		if options.Input, err = parseReadings(*input); err != nil {
+			return err
+		}
+	}
+
+	net, err := loadNet(args[0])
+	if err != nil {
+		return err
+	}
+	out, err := create(*output)
+	if err != nil {
+		return err
+	}
+	if err := render(out, net, options); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// parseReadings parse comma-separated sensor=value pairs
+func parseReadings(text string) (map[string]float64, error) {
+	res := make(map[string]float64)
+	for _, pair := range strings.Split(text, ",") {
+		eq := strings.LastIndexByte(pair, '=')
+		if eq < 0 {
+			return nil, fmt.Errorf("expected sensor=value, got %q", pair)
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(pair[eq+1:]), 64)
+		if err != nil {
+			return nil, err
+		}
+		res[strings.TrimSpace(pair[:eq])] = value
+	}
+	return res, nil
+}
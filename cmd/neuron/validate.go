@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+)
+
+// runValidate check every file decodes into a net that saves back, genes
+// within bounds included
+func runValidate(flags *flag.FlagSet, args []string) error {
+	quiet := flags.Bool("q", false, "report invalid files only")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("wrong number of arguments")
+	}
+
+	invalid := 0
+	for _, path := range flags.Args() {
+		net, err := loadNet(path)
+		if err == nil {
+			if err = net.Save(ioutil.Discard); err != nil {
+				err = fmt.Errorf("%v: %w", path, err)
+			}
+		}
+		if err != nil {
+			fmt.Println(err)
+			invalid++
+		} else if !*quiet {
+			fmt.Printf("%v: ok\n", path)
+		}
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%v of %v files invalid", invalid, flags.NArg())
+	}
+	return nil
+}
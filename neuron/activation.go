@@ -0,0 +1,102 @@
+package neuron
+
+import (
+	"fmt"
+	"math"
+)
+
+// Activation is the function a layer applies to the weighted sum of each
+// of its neurons
+type Activation uint8
+
+const (
+	// ReLU truncates positive sums to integers and zeroes the rest, just
+	// like Neuron.Compute; it is the default activation
+	ReLU Activation = iota
+	// Linear passes the weighted sum through untouched
+	Linear
+	// Step yields 1 for positive sums and 0 otherwise
+	Step
+	// Sigmoid squashes the weighted sum into (0, 1)
+	Sigmoid
+	// Tanh squashes the weighted sum into (-1, 1)
+	Tanh
+)
+
+var activationNames = []string{"relu", "linear", "step", "sigmoid", "tanh"}
+
+// ParseActivation return the activation named by str
+func ParseActivation(str string) (Activation, error) {
+	for i, name := range activationNames {
+		if name == str {
+			return Activation(i), nil
+		}
+	}
+	return ReLU, fmt.Errorf("unknown activation %q", str)
+}
+
+func (act Activation) String() string {
+	if int(act) < len(activationNames) {
+		return activationNames[act]
+	}
+	return fmt.Sprintf("activation(%d)", uint8(act))
+}
+
+// MarshalText encode the activation as its name
+func (act Activation) MarshalText() ([]byte, error) {
+	if !act.valid() {
+		return nil, fmt.Errorf("invalid activation %v", act)
+	}
+	return []byte(act.String()), nil
+}
+
+// UnmarshalText decode an activation from its name
+func (act *Activation) UnmarshalText(text []byte) error {
+	value, err := ParseActivation(string(text))
+	if err != nil {
+		return err
+	}
+	*act = value
+	return nil
+}
+
+func (act Activation) valid() bool {
+	return int(act) < len(activationNames)
+}
+
+func (act Activation) apply(sum float64) float64 {
+	switch act {
+	case Linear:
+		return sum
+	case Step:
+		if sum > 0 {
+			return 1
+		}
+		return 0
+	case Sigmoid:
+		return 1 / (1 + math.Exp(-sum))
+	case Tanh:
+		return math.Tanh(sum)
+	default:
+		if sum > 0 {
+			return float64(int(sum))
+		}
+		return 0
+	}
+}
+
+// fires tells whether an output of this activation triggers its action
+func (act Activation) fires(value float64) bool {
+	if act == Sigmoid {
+		return value > 0.5
+	}
+	return value > 0
+}
+
+func weightedSum(neu Neuron, data []float64) float64 {
+	sum := 0.0
+	for index, value := range data {
+		sum += value * float64(neu.GetGene(index))
+	}
+	return sum
+}
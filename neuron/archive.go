@@ -0,0 +1,439 @@
+package neuron
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+)
+
+// An archive holds many nets, laid out as:
+//
+//	magic    "\x89NRA"
+//	version  uint16
+//	entries  "ENTR" sections: name, fitness and the net as Save writes it
+//	index    an "INDX" section listing name, fitness, offset and size of
+//	         every entry
+//	trailer  uint64 offset of the index, then the magic again
+//
+// Sections are a four-byte tag, a uint32 body length and the body, as in V2
+// nets. The index lets a net be loaded without reading the others, while the
+// entries alone can be read as a stream. Appending leaves the archive as it
+// is: the new entries follow its trailer, and a new index and trailer follow
+// them on Close. Until then, the last complete trailer is the one read.
+var archiveMagic = [4]byte{0x89, 'N', 'R', 'A'}
+
+const (
+	archiveVersion = 1
+	archiveHeader  = 6
+	archiveTrailer = 12
+)
+
+// ArchiveEntry describes a net held by an archive
+type ArchiveEntry struct {
+	Name    string
+	Fitness float64
+	Offset  int64 // from the start of the archive
+	Size    int64
+}
+
+// ArchiveWriter adds nets to an archive, writing its index on Close; an
+// empty name stands for the MetaID metadata of the net
+type ArchiveWriter interface {
+	Add(net NeuralNet, name string, fitness float64) error
+	Close() error
+}
+
+// Archive gives access to the nets of an archive by index
+type Archive interface {
+	GetEntries() []ArchiveEntry
+	Find(name string) (int, bool)
+	Load(int) (NeuralNet, error)
+}
+
+// ArchiveReader reads the nets of an archive in order
+type ArchiveReader interface {
+	// Next return the next net, or io.EOF after the last one
+	Next() (ArchiveEntry, NeuralNet, error)
+}
+
+type archiveWriter struct {
+	out     io.Writer
+	offset  int64
+	entries []ArchiveEntry
+	closed  bool
+}
+
+type archive struct {
+	input   io.ReaderAt
+	entries []ArchiveEntry
+	index   int64 // offset of the index
+}
+
+type archiveReader struct {
+	input  io.Reader
+	offset int64
+	ended  bool // right after a trailer, where the archive may end
+}
+
+// NewArchiveWriter start a new archive
+func NewArchiveWriter(out io.Writer) (ArchiveWriter, error) {
+	var head [archiveHeader]byte
+	copy(head[:], archiveMagic[:])
+	binary.BigEndian.PutUint16(head[4:], archiveVersion)
+	if _, err := out.Write(head[:]); err != nil {
+		return nil, err
+	}
+	return &archiveWriter{out: out, offset: archiveHeader}, nil
+}
+
+// AppendArchive reopen an archive to add nets
+func AppendArchive(file io.ReadWriteSeeker) (ArchiveWriter, error) {
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	base, err := openArchive(readerAt{file}, size)
+	if err != nil {
+		return nil, err
+	}
+	// The new entries follow the trailer, keeping the archive readable
+	if _, err := file.Seek(size, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return &archiveWriter{out: file, offset: size, entries: base.entries}, nil
+}
+
+func (writer *archiveWriter) Add(net NeuralNet, name string, fitness float64) error {
+	if writer.closed {
+		return fmt.Errorf("archive closed")
+	}
+	if name == "" {
+		name = net.GetMetadata()[MetaID]
+	}
+	var body bytes.Buffer
+	var current [8]byte
+	writeString(&body, name)
+	binary.BigEndian.PutUint64(current[:], math.Float64bits(fitness))
+	body.Write(current[:])
+	if err := net.Save(&body); err != nil {
+		return err
+	}
+	if uint64(body.Len()) > math.MaxUint32 {
+		return &OverflowError{Field: "archive entry", Value: uint64(body.Len()), Max: math.MaxUint32}
+	}
+
+	var buf bytes.Buffer
+	writeSection(&buf, "ENTR", body.Bytes())
+	if _, err := writer.out.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	writer.entries = append(writer.entries, ArchiveEntry{
+		Name:    name,
+		Fitness: fitness,
+		Offset:  writer.offset,
+		Size:    int64(buf.Len()),
+	})
+	writer.offset += int64(buf.Len())
+	return nil
+}
+
+func (writer *archiveWriter) Close() error {
+	if writer.closed {
+		return nil
+	}
+	writer.closed = true
+
+	var body bytes.Buffer
+	var current [8]byte
+	binary.BigEndian.PutUint32(current[:], uint32(len(writer.entries)))
+	body.Write(current[:4])
+	for _, entry := range writer.entries {
+		writeString(&body, entry.Name)
+		binary.BigEndian.PutUint64(current[:], math.Float64bits(entry.Fitness))
+		body.Write(current[:])
+		binary.BigEndian.PutUint64(current[:], uint64(entry.Offset))
+		body.Write(current[:])
+		binary.BigEndian.PutUint64(current[:], uint64(entry.Size))
+		body.Write(current[:])
+	}
+	if uint64(body.Len()) > math.MaxUint32 {
+		return &OverflowError{Field: "archive index", Value: uint64(body.Len()), Max: math.MaxUint32}
+	}
+
+	var buf bytes.Buffer
+	writeSection(&buf, "INDX", body.Bytes())
+	binary.BigEndian.PutUint64(current[:], uint64(writer.offset))
+	buf.Write(current[:])
+	buf.Write(archiveMagic[:])
+	_, err := writer.out.Write(buf.Bytes())
+	return err
+}
+
+// OpenArchive read the index of an archive of the given size
+func OpenArchive(input io.ReaderAt, size int64) (Archive, error) {
+	arc, err := openArchive(input, size)
+	if err != nil {
+		return nil, err
+	}
+	return arc, nil
+}
+
+func openArchive(input io.ReaderAt, size int64) (*archive, error) {
+	var head [archiveHeader]byte
+	if err := readAt(input, head[:], 0); err != nil {
+		return nil, decodeError("archive header", err)
+	}
+	if err := checkArchiveHeader(head); err != nil {
+		return nil, err
+	}
+	if size < archiveHeader+archiveTrailer+8 {
+		return nil, &DecodeError{What: "archive trailer", Err: ErrTruncated}
+	}
+	var trailer [archiveTrailer]byte
+	if err := readAt(input, trailer[:], size-archiveTrailer); err != nil {
+		return nil, decodeError("archive trailer", err)
+	}
+	if !bytes.Equal(trailer[8:], archiveMagic[:]) {
+		// An append not closed yet, or interrupted
+		if end := lastTrailer(input, size); end > 0 {
+			return openArchive(input, end)
+		}
+		return nil, malformed("archive trailer")
+	}
+	offset := binary.BigEndian.Uint64(trailer[:])
+	end := uint64(size - archiveTrailer)
+	if offset < archiveHeader || offset > end-8 {
+		return nil, malformed("archive index offset %v", offset)
+	}
+
+	section := make([]byte, end-offset)
+	if err := readAt(input, section, int64(offset)); err != nil {
+		return nil, decodeError("archive index", err)
+	}
+	if string(section[:4]) != "INDX" || uint64(binary.BigEndian.Uint32(section[4:])) != uint64(len(section)-8) {
+		return nil, malformed("archive index")
+	}
+	entries, err := decodeIndex(section[8:], int64(offset))
+	if err != nil {
+		return nil, err
+	}
+	return &archive{input: input, entries: entries, index: int64(offset)}, nil
+}
+
+// lastTrailer walk the sections of an archive, returning the end of its last
+// complete trailer, or 0 for none
+func lastTrailer(input io.ReaderAt, size int64) int64 {
+	var res int64
+	var head [archiveTrailer]byte
+	for offset := int64(archiveHeader); offset+8 <= size; {
+		if err := readAt(input, head[:8], offset); err != nil {
+			break
+		}
+		offset += 8 + int64(binary.BigEndian.Uint32(head[4:]))
+		if string(head[:4]) != "INDX" {
+			continue
+		}
+		if offset+archiveTrailer > size || readAt(input, head[:], offset) != nil || !bytes.Equal(head[8:], archiveMagic[:]) {
+			break
+		}
+		offset += archiveTrailer
+		res = offset
+	}
+	return res
+}
+
+// decodeIndex read the entries of an index, which must lie before it
+func decodeIndex(body []byte, limit int64) ([]ArchiveEntry, error) {
+	input := bytes.NewReader(body)
+	count, err := readUint32(input)
+	if err != nil {
+		return nil, decodeError("archive index", err)
+	}
+	if uint64(count) > uint64(input.Len()/28) {
+		return nil, malformed("%v archive entries in %v bytes", count, input.Len())
+	}
+	res := make([]ArchiveEntry, count)
+	var current [24]byte
+	for i := range res {
+		size, err := readUint32(input)
+		if err != nil {
+			return nil, decodeError("archive index", err)
+		}
+		if uint64(size)+24 > uint64(input.Len()) {
+			return nil, &DecodeError{What: fmt.Sprintf("archive entry %v", i), Err: ErrTruncated}
+		}
+		name := make([]byte, size)
+		input.Read(name)
+		input.Read(current[:])
+		res[i] = ArchiveEntry{
+			Name:    string(name),
+			Fitness: math.Float64frombits(binary.BigEndian.Uint64(current[:])),
+			Offset:  int64(binary.BigEndian.Uint64(current[8:])),
+			Size:    int64(binary.BigEndian.Uint64(current[16:])),
+		}
+		if entry := res[i]; entry.Offset < archiveHeader || entry.Size < 8 || entry.Size > limit-entry.Offset {
+			return nil, malformed("archive entry %v: %v bytes at offset %v", i, entry.Size, entry.Offset)
+		}
+	}
+	if input.Len() > 0 {
+		return nil, malformed("%v trailing bytes after archive index", input.Len())
+	}
+	return res, nil
+}
+
+func (arc archive) GetEntries() []ArchiveEntry {
+	res := make([]ArchiveEntry, len(arc.entries))
+	copy(res, arc.entries)
+	return res
+}
+
+// Find return the index of the first entry of the given name
+func (arc archive) Find(name string) (int, bool) {
+	for i, entry := range arc.entries {
+		if entry.Name == name {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+func (arc archive) Load(index int) (NeuralNet, error) {
+	if index < 0 || index >= len(arc.entries) {
+		return nil, fmt.Errorf("no archive entry %v", index)
+	}
+	entry := arc.entries[index]
+	reader := &archiveReader{input: io.NewSectionReader(arc.input, entry.Offset, entry.Size), offset: entry.Offset}
+	loaded, net, err := reader.Next()
+	if err == io.EOF {
+		return nil, malformed("archive entry %v missing", index)
+	} else if err != nil {
+		return nil, err
+	}
+	if loaded.Name != entry.Name || loaded.Size != entry.Size {
+		return nil, malformed("archive entry %v does not match the index", index)
+	}
+	return net, nil
+}
+
+// NewArchiveReader start reading an archive as a stream
+func NewArchiveReader(input io.Reader) (ArchiveReader, error) {
+	var head [archiveHeader]byte
+	if _, err := io.ReadFull(input, head[:]); err != nil {
+		return nil, decodeError("archive header", err)
+	}
+	if err := checkArchiveHeader(head); err != nil {
+		return nil, err
+	}
+	return &archiveReader{input: input, offset: archiveHeader}, nil
+}
+
+func (reader *archiveReader) Next() (ArchiveEntry, NeuralNet, error) {
+	for {
+		var head [8]byte
+		if _, err := io.ReadFull(reader.input, head[:]); err == io.EOF && reader.ended {
+			return ArchiveEntry{}, nil, io.EOF
+		} else if err != nil {
+			return ArchiveEntry{}, nil, decodeError("archive section", err)
+		}
+		reader.ended = false
+		tag := string(head[:4])
+		size := binary.BigEndian.Uint32(head[4:])
+		entry := ArchiveEntry{Offset: reader.offset, Size: 8 + int64(size)}
+		reader.offset += entry.Size
+
+		switch tag {
+		case "INDX":
+			// Entries appended later may follow the trailer
+			var trailer [archiveTrailer]byte
+			if _, err := io.CopyN(ioutil.Discard, reader.input, int64(size)); err != nil {
+				return ArchiveEntry{}, nil, decodeError("archive index", err)
+			}
+			if _, err := io.ReadFull(reader.input, trailer[:]); err != nil {
+				return ArchiveEntry{}, nil, decodeError("archive trailer", err)
+			}
+			if !bytes.Equal(trailer[8:], archiveMagic[:]) {
+				return ArchiveEntry{}, nil, malformed("archive trailer")
+			}
+			reader.offset += archiveTrailer
+			reader.ended = true
+		case "ENTR":
+			net, err := reader.entry(&entry, size)
+			return entry, net, err
+		default:
+			if _, err := io.CopyN(ioutil.Discard, reader.input, int64(size)); err != nil {
+				return ArchiveEntry{}, nil, decodeError(fmt.Sprintf("archive section %q", tag), err)
+			}
+		}
+	}
+}
+
+// entry read the body of an entry section
+func (reader *archiveReader) entry(entry *ArchiveEntry, size uint32) (NeuralNet, error) {
+	body, err := readBody(reader.input, size)
+	if err != nil {
+		return nil, decodeError("archive entry", err)
+	}
+	input := bytes.NewReader(body)
+	length, err := readUint32(input)
+	if err != nil || uint64(length)+8 > uint64(input.Len()) {
+		return nil, &DecodeError{What: "archive entry name", Err: ErrTruncated}
+	}
+	name := make([]byte, length)
+	input.Read(name)
+	var current [8]byte
+	input.Read(current[:])
+	entry.Name = string(name)
+	entry.Fitness = math.Float64frombits(binary.BigEndian.Uint64(current[:]))
+
+	net, err := LoadNet(input)
+	if err == io.EOF {
+		return nil, &DecodeError{What: "archive entry " + entry.Name, Err: ErrTruncated}
+	} else if err != nil {
+		return nil, err
+	}
+	if input.Len() > 0 {
+		return nil, malformed("%v trailing bytes in archive entry %v", input.Len(), entry.Name)
+	}
+	return net, nil
+}
+
+func checkArchiveHeader(head [archiveHeader]byte) error {
+	if !bytes.Equal(head[:4], archiveMagic[:]) {
+		return ErrUnknownFormat
+	}
+	if version := binary.BigEndian.Uint16(head[4:]); version != archiveVersion {
+		return fmt.Errorf("unsupported archive version %v", version)
+	}
+	return nil
+}
+
+func writeString(buf *bytes.Buffer, value string) {
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(value)))
+	buf.Write(size[:])
+	buf.WriteString(value)
+}
+
+// readAt fill the buffer, ignoring io.EOF when it comes with the last byte
+func readAt(input io.ReaderAt, buf []byte, offset int64) error {
+	n, err := input.ReadAt(buf, offset)
+	if n == len(buf) {
+		return nil
+	}
+	return err
+}
+
+// readerAt read a seeker at random offsets
+type readerAt struct {
+	input io.ReadSeeker
+}
+
+func (reader readerAt) ReadAt(buf []byte, offset int64) (int, error) {
+	if _, err := reader.input.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.ReadFull(reader.input, buf)
+}
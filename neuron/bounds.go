@@ -0,0 +1,202 @@
+package neuron
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// MinGene is the lowest gene the file format can hold
+	MinGene = math.MinInt32
+	// MaxGene is the highest gene the file format can hold
+	MaxGene = math.MaxInt32
+)
+
+// BoundsMode tells how mutation brings genes back inside their bounds
+type BoundsMode uint8
+
+const (
+	// Clamp moves an outlying gene to the nearest bound
+	Clamp BoundsMode = iota
+	// Reflect bounces an outlying gene back off the bound it crossed
+	Reflect
+)
+
+var boundsModeNames = []string{"clamp", "reflect"}
+
+func (mode BoundsMode) String() string {
+	if int(mode) < len(boundsModeNames) {
+		return boundsModeNames[mode]
+	}
+	return fmt.Sprintf("mode(%d)", uint8(mode))
+}
+
+// MarshalText encode the mode as its name
+func (mode BoundsMode) MarshalText() ([]byte, error) {
+	if int(mode) >= len(boundsModeNames) {
+		return nil, fmt.Errorf("invalid bounds mode %v", mode)
+	}
+	return []byte(mode.String()), nil
+}
+
+// UnmarshalText decode a mode from its name
+func (mode *BoundsMode) UnmarshalText(text []byte) error {
+	for i, name := range boundsModeNames {
+		if name == string(text) {
+			*mode = BoundsMode(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown bounds mode %q", text)
+}
+
+// Bounds restricts the values genes may take
+type Bounds struct {
+	Min  int        `json:"min"`
+	Max  int        `json:"max"`
+	Mode BoundsMode `json:"mode"`
+}
+
+// DefaultBounds are the bounds of nets that declare none
+var DefaultBounds = Bounds{Min: MinGene, Max: MaxGene, Mode: Clamp}
+
+// GeneRangeError reports a gene outside its bounds; Layer and Neuron are -1
+// for a lone neuron
+type GeneRangeError struct {
+	Layer, Neuron, Gene int
+	Value               int
+	Bounds              Bounds
+}
+
+func (err *GeneRangeError) Error() string {
+	if err.Layer < 0 {
+		return fmt.Sprintf("gene %v: value %v out of bounds [%v, %v]", err.Gene, err.Value, err.Bounds.Min, err.Bounds.Max)
+	}
+	return fmt.Sprintf(
+		"layer %v, neuron %v, gene %v: value %v out of bounds [%v, %v]",
+		err.Layer, err.Neuron, err.Gene, err.Value, err.Bounds.Min, err.Bounds.Max,
+	)
+}
+
+func (bounds Bounds) String() string {
+	return fmt.Sprintf("[%v, %v] %v", bounds.Min, bounds.Max, bounds.Mode)
+}
+
+// Contains tells whether gene is inside the bounds
+func (bounds Bounds) Contains(gene int) bool {
+	return gene >= bounds.Min && gene <= bounds.Max
+}
+
+// Apply bring gene inside the bounds
+func (bounds Bounds) Apply(gene int) int {
+	if bounds.Contains(gene) {
+		return gene
+	}
+	if bounds.Mode == Reflect && bounds.Max > bounds.Min {
+		width := int64(bounds.Max) - int64(bounds.Min)
+		offset := (int64(gene) - int64(bounds.Min)) % (2 * width)
+		if offset < 0 {
+			offset += 2 * width
+		}
+		if offset > width {
+			offset = 2*width - offset
+		}
+		return int(int64(bounds.Min) + offset)
+	}
+	if gene < bounds.Min {
+		return bounds.Min
+	}
+	return bounds.Max
+}
+
+func (bounds Bounds) validate() error {
+	if bounds.Min > bounds.Max {
+		return fmt.Errorf("invalid bounds [%v, %v]", bounds.Min, bounds.Max)
+	}
+	if !DefaultBounds.Contains(bounds.Min) || !DefaultBounds.Contains(bounds.Max) {
+		return fmt.Errorf("bounds [%v, %v] exceed [%v, %v]", bounds.Min, bounds.Max, MinGene, MaxGene)
+	}
+	if bounds.Mode != Clamp && bounds.Mode != Reflect {
+		return fmt.Errorf("invalid bounds mode %v", bounds.Mode)
+	}
+	return nil
+}
+
+// WithBounds declare the gene bounds of the net: either one for every layer
+// or one per layer
+func WithBounds(bounds ...Bounds) Option {
+	return func(net *neuralnet) error {
+		switch len(bounds) {
+		case 1:
+			net.bounds = make([]Bounds, len(net.neurons))
+			for i := range net.bounds {
+				net.bounds[i] = bounds[0]
+			}
+		case len(net.neurons):
+			net.bounds = make([]Bounds, len(bounds))
+			copy(net.bounds, bounds)
+		default:
+			return fmt.Errorf("expected 1 or %v bounds, got %v", len(net.neurons), len(bounds))
+		}
+		for i, current := range net.bounds {
+			if err := current.validate(); err != nil {
+				return fmt.Errorf("layer %v: %v", i, err)
+			}
+		}
+		return net.checkBounds()
+	}
+}
+
+func (net neuralnet) GetBounds(index int) Bounds {
+	if index >= len(net.bounds) {
+		return DefaultBounds
+	}
+	return net.bounds[index]
+}
+
+func (net neuralnet) checkBounds() error {
+	for i, layer := range net.neurons {
+		bounds := net.GetBounds(i)
+		for j, neuron := range layer {
+			for k := 0; k < neuron.GetSize(); k++ {
+				if value := neuron.GetGene(k); !bounds.Contains(value) {
+					return &GeneRangeError{i, j, k, value, bounds}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkGenes report the first gene the file format cannot hold
+func checkGenes(layers []Layer) error {
+	for i, layer := range layers {
+		for j, neu := range layer {
+			for k := 0; k < neu.GetSize(); k++ {
+				if value := neu.GetGene(k); !DefaultBounds.Contains(value) {
+					return &GeneRangeError{i, j, k, value, DefaultBounds}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func boundNeuron(neu Neuron, bounds Bounds) Neuron {
+	res := make(neuron, neu.GetSize())
+	for i := range res {
+		res[i] = bounds.Apply(neu.GetGene(i))
+	}
+	return res
+}
+
+func saturatingAdd(value, delta int) int {
+	sum := int64(value) + int64(delta)
+	if sum < MinGene {
+		return MinGene
+	}
+	if sum > MaxGene {
+		return MaxGene
+	}
+	return int(sum)
+}
@@ -0,0 +1,117 @@
+package neuron
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// LayerSpec describes a layer built by BuildNet
+type LayerSpec struct {
+	Width       int // ignored on the output layer, which holds one neuron per action
+	Initialiser Initialiser
+	Activation  Activation
+}
+
+// Topology describes the shape of a neural net to be built
+type Topology struct {
+	Sensors []string
+	Actions []string
+	Hidden  []LayerSpec
+	Output  LayerSpec
+	Rand    *rand.Rand // nil means the global math/rand source
+}
+
+// NewTopology describe a net with default hidden layers of the given widths
+func NewTopology(sensors, actions []string, widths ...int) Topology {
+	hidden := make([]LayerSpec, len(widths))
+	for i, width := range widths {
+		hidden[i] = LayerSpec{Width: width}
+	}
+	return Topology{Sensors: sensors, Actions: actions, Hidden: hidden}
+}
+
+// ParseTopology describe a net from a spec string like "2-3-2-1", where the
+// first and last numbers are the sensor and action counts
+func ParseTopology(spec string, sensors, actions []string) (Topology, error) {
+	fields := strings.Split(strings.TrimSpace(spec), "-")
+	if len(fields) < 2 {
+		return Topology{}, fmt.Errorf("topology %q: expected at least sensors and actions", spec)
+	}
+
+	widths := make([]int, len(fields))
+	for i, field := range fields {
+		width, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return Topology{}, fmt.Errorf("topology %q: %v", spec, err)
+		}
+		widths[i] = width
+	}
+
+	if count := len(usort(sensors)); widths[0] != count {
+		return Topology{}, fmt.Errorf("topology %q: expected %v sensors, got %v", spec, widths[0], count)
+	}
+	last := widths[len(widths)-1]
+	if count := len(usort(actions)); last != count {
+		return Topology{}, fmt.Errorf("topology %q: expected %v actions, got %v", spec, last, count)
+	}
+
+	return NewTopology(sensors, actions, widths[1:len(widths)-1]...), nil
+}
+
+// BuildNet create a new random neural net with the given topology
+func BuildNet(topology Topology, options ...Option) (NeuralNet, error) {
+	sensors := usort(topology.Sensors)
+	if len(sensors) == 0 {
+		return nil, fmt.Errorf("no sensor supplied")
+	}
+	layers, activations, err := topology.buildLayers(len(sensors))
+	if err != nil {
+		return nil, err
+	}
+	options = append([]Option{WithActivations(activations...)}, options...)
+	return NewNeuralNet(sensors, topology.Actions, layers, options...)
+}
+
+// buildLayers draw the hidden and output layers reading size inputs
+func (topology Topology) buildLayers(size int) ([]Layer, []Activation, error) {
+	actions := usort(topology.Actions)
+	if len(actions) == 0 {
+		return nil, nil, fmt.Errorf("no action supplied")
+	}
+
+	specs := make([]LayerSpec, len(topology.Hidden)+1)
+	copy(specs, topology.Hidden)
+	specs[len(specs)-1] = topology.Output
+	specs[len(specs)-1].Width = len(actions)
+
+	layers := make([]Layer, len(specs))
+	activations := make([]Activation, len(specs))
+
+	for i, spec := range specs {
+		if spec.Width <= 0 {
+			return nil, nil, fmt.Errorf("layer %v: invalid width %v", i, spec.Width)
+		}
+		initialiser := spec.Initialiser
+		if initialiser == nil {
+			initialiser = defaultInitialiser
+		}
+		genes := initialiser(topology.Rand, spec.Width, size)
+		if len(genes) != spec.Width {
+			return nil, nil, fmt.Errorf("layer %v: initialiser returned %v neurons, expected %v", i, len(genes), spec.Width)
+		}
+
+		layer := make(Layer, spec.Width)
+		for j, current := range genes {
+			if len(current) != size {
+				return nil, nil, fmt.Errorf("layer %v, neuron %v: initialiser returned %v genes, expected %v", i, j, len(current), size)
+			}
+			layer[j] = neuron(current)
+		}
+		layers[i] = layer
+		activations[i] = spec.Activation
+		size = spec.Width
+	}
+	return layers, activations, nil
+}
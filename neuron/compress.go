@@ -0,0 +1,99 @@
+package neuron
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+)
+
+// SaveCompressed save the net in the current format, compressed by gzip at
+// the given level (gzip.DefaultCompression, gzip.BestSpeed…)
+func (net neuralnet) SaveCompressed(out io.Writer, level int) error {
+	writer, err := gzip.NewWriterLevel(out, level)
+	if err != nil {
+		return err
+	}
+	if err := net.Save(writer); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// isGzip tell whether a header starts a gzip member using deflate; legacy
+// headers never match, their third byte being zero
+func isGzip(buf [4]byte) bool {
+	return buf[0] == 0x1f && buf[1] == 0x8b && buf[2] == 0x08
+}
+
+// loadCompressed read a gzip compressed net whose first four bytes were
+// already consumed
+func loadCompressed(head [4]byte, input io.Reader) (NeuralNet, error) {
+	reader, err := gzip.NewReader(&byteReader{peeked: head[:], input: input})
+	if err != nil {
+		return nil, compressionError("gzip header", err)
+	}
+	// Stop at the end of this member, leaving whatever follows unread
+	reader.Multistream(false)
+
+	var buf [4]byte
+	if _, err := io.ReadFull(reader, buf[:]); err != nil {
+		return nil, compressionError("header", err)
+	}
+	net, err := loadNet(buf, reader)
+	if errors.Is(err, gzip.ErrChecksum) {
+		return nil, ErrChecksum
+	} else if err != nil {
+		return nil, err
+	}
+	// Reach the end of the member, so the gzip checksum gets verified
+	if n, err := io.ReadFull(reader, buf[:1]); n > 0 {
+		return nil, malformed("trailing data in compressed net")
+	} else if err != io.EOF {
+		return nil, compressionError("gzip trailer", err)
+	}
+	return net, nil
+}
+
+// compressionError report gzip checksum mismatches as ErrChecksum, and
+// other decompression failures as decode errors
+func compressionError(what string, err error) error {
+	switch {
+	case errors.Is(err, gzip.ErrChecksum):
+		return ErrChecksum
+	case errors.Is(err, gzip.ErrHeader):
+		return malformed("%v: %v", what, err)
+	}
+	return decodeError(what, err)
+}
+
+// byteReader give back the peeked bytes, then read the input without
+// buffering, so the decompressor consumes nothing past the compressed net
+type byteReader struct {
+	peeked []byte
+	input  io.Reader
+	one    [1]byte
+}
+
+func (reader *byteReader) Read(buf []byte) (int, error) {
+	if len(reader.peeked) > 0 {
+		n := copy(buf, reader.peeked)
+		reader.peeked = reader.peeked[n:]
+		return n, nil
+	}
+	return reader.input.Read(buf)
+}
+
+func (reader *byteReader) ReadByte() (byte, error) {
+	if len(reader.peeked) > 0 {
+		value := reader.peeked[0]
+		reader.peeked = reader.peeked[1:]
+		return value, nil
+	}
+	if input, ok := reader.input.(io.ByteReader); ok {
+		return input.ReadByte()
+	}
+	if _, err := io.ReadFull(reader.input, reader.one[:]); err != nil {
+		return 0, err
+	}
+	return reader.one[0], nil
+}
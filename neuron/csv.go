@@ -0,0 +1,143 @@
+package neuron
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// EvaluateOptions tunes EvaluateCSV
+type EvaluateOptions struct {
+	// Raw writes the activation of each action rather than whether it fires
+	Raw bool
+	// Keep copies every input column ahead of the actions, so rows can be
+	// told apart by their own identifiers
+	Keep bool
+	// Comma is the field delimiter of both input and output, ',' by default
+	Comma rune
+}
+
+// EvaluateCSV stream the rows of a CSV document through the net, writing a
+// row of actions per row of readings. The header names the columns; those
+// named after sensors are read, others ignored. A sensor without a column
+// or with an empty cell reads its schema default, if any. It return the
+// number of rows evaluated, which are written out even when a later row
+// fails.
+func EvaluateCSV(in io.Reader, out io.Writer, net NeuralNet, options EvaluateOptions) (count int, err error) {
+	reader := csv.NewReader(in)
+	writer := csv.NewWriter(out)
+	defer func() {
+		writer.Flush()
+		if err == nil {
+			err = writer.Error()
+		}
+	}()
+	if options.Comma != 0 {
+		reader.Comma = options.Comma
+		writer.Comma = options.Comma
+	}
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return 0, fmt.Errorf("missing header")
+	} else if err != nil {
+		return 0, err
+	}
+	columns, err := sensorColumns(net, header)
+	if err != nil {
+		return 0, err
+	}
+
+	actions := net.GetActions()
+	var outHeader []string
+	if options.Keep {
+		outHeader = append(outHeader, header...)
+	}
+	if err := writer.Write(append(outHeader, actions...)); err != nil {
+		return 0, err
+	}
+
+	schema := net.GetSchema()
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return count, err
+		}
+		row := count + 2 // the header being row 1
+		input := make(map[string]float64, len(columns))
+		for sensor, column := range columns {
+			cell := strings.TrimSpace(record[column])
+			if cell == "" {
+				continue
+			}
+			value, err := strconv.ParseFloat(cell, 64)
+			if err != nil {
+				return count, fmt.Errorf("row %v, column %v: invalid reading %q", row, quoteName(sensor), cell)
+			}
+			input[sensor] = value
+		}
+		for _, sensor := range net.GetSensors() {
+			if _, ok := input[sensor]; !ok && schema[sensor].Default == nil {
+				return count, fmt.Errorf("row %v: missing reading for sensor %v", row, quoteName(sensor))
+			}
+		}
+
+		var res []string
+		if options.Keep {
+			res = append(res, record...)
+		}
+		if options.Raw {
+			outputs, err := net.Activate(input)
+			if err != nil {
+				return count, fmt.Errorf("row %v: %w", row, err)
+			}
+			for _, action := range actions {
+				res = append(res, strconv.FormatFloat(outputs[action], 'g', -1, 64))
+			}
+		} else {
+			fired, err := net.Compute(input)
+			if err != nil {
+				return count, fmt.Errorf("row %v: %w", row, err)
+			}
+			for _, action := range actions {
+				res = append(res, strconv.FormatBool(fired[action]))
+			}
+		}
+		if err := writer.Write(res); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// sensorColumns return the column of each sensor named in the header,
+// failing for sensors neither named nor defaulted
+func sensorColumns(net NeuralNet, header []string) (map[string]int, error) {
+	sensors := make(map[string]bool)
+	for _, sensor := range net.GetSensors() {
+		sensors[sensor] = true
+	}
+	res := make(map[string]int)
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if !sensors[name] {
+			continue
+		}
+		if _, ok := res[name]; ok {
+			return nil, fmt.Errorf("column %v repeated", quoteName(name))
+		}
+		res[name] = i
+	}
+	schema := net.GetSchema()
+	for _, sensor := range net.GetSensors() {
+		if _, ok := res[sensor]; !ok && schema[sensor].Default == nil {
+			return nil, fmt.Errorf("no column for sensor %v", quoteName(sensor))
+		}
+	}
+	return res, nil
+}
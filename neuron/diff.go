@@ -0,0 +1,446 @@
+package neuron
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// NetDiff reports how a net differs from another, structurally
+type NetDiff struct {
+	AddedSensors   []string
+	RemovedSensors []string
+	AddedActions   []string
+	RemovedActions []string
+	// WidthsBefore and WidthsAfter are the widths of each layer
+	WidthsBefore []int
+	WidthsAfter  []int
+	// Settings lists the changed activations and bounds of the layers both
+	// nets have, normalisers of the sensors they share and, for comparable
+	// nets, frozen flags of matched neurons
+	Settings []SettingDelta
+	// Comparable tells whether genes could be matched: both nets have the
+	// same hidden widths, first layer genes being matched by sensor and last
+	// layer neurons by action
+	Comparable bool
+	Genes      []GeneDelta // changed genes only
+	Stats      GeneStats
+}
+
+// GeneDelta locates a changed gene, indices being those of the later net
+type GeneDelta struct {
+	Layer, Neuron, Gene int
+	Before, After       int
+}
+
+// SettingDelta reports a changed setting, Before and After describing it
+type SettingDelta struct {
+	Setting       string // "activation", "bounds", "normaliser" or "frozen"
+	Layer, Neuron int    // -1 when not applying
+	Sensor        string // normalisers only
+	Before, After string
+}
+
+func (delta SettingDelta) String() string {
+	place := delta.Sensor
+	if delta.Neuron >= 0 {
+		place = fmt.Sprintf("%v.%v", delta.Layer, delta.Neuron)
+	} else if delta.Layer >= 0 {
+		place = fmt.Sprint(delta.Layer)
+	}
+	return fmt.Sprintf("%v %v: %v -> %v", delta.Setting, place, delta.Before, delta.After)
+}
+
+// Delta return the change of the gene
+func (delta GeneDelta) Delta() int {
+	return delta.After - delta.Before
+}
+
+// GeneStats summarises the changes of matched genes, averaged over every
+// matched gene, unchanged ones included
+type GeneStats struct {
+	Compared int // matched genes
+	Changed  int
+	Mean     float64
+	MeanAbs  float64
+	RMS      float64
+	MaxAbs   int
+}
+
+// Diff compare the structure and genes of two nets
+func Diff(before, after NeuralNet) NetDiff {
+	var diff NetDiff
+	diff.AddedSensors, diff.RemovedSensors = setChanges(before.GetSensors(), after.GetSensors())
+	diff.AddedActions, diff.RemovedActions = setChanges(before.GetActions(), after.GetActions())
+	layersBefore, layersAfter := netLayers(before), netLayers(after)
+	diff.WidthsBefore, diff.WidthsAfter = widths(layersBefore), widths(layersAfter)
+	diff.Settings = settingChanges(before, after, minInt(len(layersBefore), len(layersAfter)))
+
+	count := len(layersAfter)
+	diff.Comparable = count == len(layersBefore)
+	for i := 0; diff.Comparable && i < count-1; i++ {
+		diff.Comparable = len(layersBefore[i]) == len(layersAfter[i])
+	}
+	if !diff.Comparable {
+		return diff
+	}
+
+	// Match inputs of the first layer and neurons of the last one by name
+	inputs := nameIndices(before.GetSensors(), after.GetSensors())
+	outputs := nameIndices(before.GetActions(), after.GetActions())
+	var sum, sumAbs, sumSquares float64
+	for i, layer := range layersAfter {
+		for j, neu := range layer {
+			source := j
+			if i == count-1 {
+				source = outputs[j]
+			}
+			if source < 0 {
+				continue
+			}
+			if frozen := after.IsFrozen(i, j); frozen != before.IsFrozen(i, source) {
+				diff.Settings = append(diff.Settings, SettingDelta{
+					Setting: "frozen", Layer: i, Neuron: j,
+					Before: fmt.Sprint(!frozen), After: fmt.Sprint(frozen),
+				})
+			}
+			other := layersBefore[i][source]
+			for k := 0; k < neu.GetSize(); k++ {
+				input := k
+				if i == 0 {
+					input = inputs[k]
+				}
+				if input < 0 {
+					continue
+				}
+				delta := GeneDelta{Layer: i, Neuron: j, Gene: k, Before: other.GetGene(input), After: neu.GetGene(k)}
+				diff.Stats.Compared++
+				if change := delta.Delta(); change != 0 {
+					diff.Genes = append(diff.Genes, delta)
+					diff.Stats.Changed++
+					sum += float64(change)
+					sumAbs += math.Abs(float64(change))
+					sumSquares += float64(change) * float64(change)
+					if abs(change) > diff.Stats.MaxAbs {
+						diff.Stats.MaxAbs = abs(change)
+					}
+				}
+			}
+		}
+	}
+	if diff.Stats.Compared > 0 {
+		compared := float64(diff.Stats.Compared)
+		diff.Stats.Mean = sum / compared
+		diff.Stats.MeanAbs = sumAbs / compared
+		diff.Stats.RMS = math.Sqrt(sumSquares / compared)
+	}
+	return diff
+}
+
+// settingChanges compare the activations and bounds of the first layers
+// and the normalisers of shared sensors
+func settingChanges(before, after NeuralNet, layers int) []SettingDelta {
+	var res []SettingDelta
+	for i := 0; i < layers; i++ {
+		if activation := after.GetActivation(i); activation != before.GetActivation(i) {
+			res = append(res, SettingDelta{
+				Setting: "activation", Layer: i, Neuron: -1,
+				Before: before.GetActivation(i).String(), After: activation.String(),
+			})
+		}
+		if bounds := after.GetBounds(i); bounds != before.GetBounds(i) {
+			res = append(res, SettingDelta{
+				Setting: "bounds", Layer: i, Neuron: -1,
+				Before: before.GetBounds(i).String(), After: bounds.String(),
+			})
+		}
+	}
+	describe := func(net NeuralNet, sensor string) string {
+		if norm, ok := net.GetNormaliser(sensor); ok {
+			return norm.String()
+		}
+		return "none"
+	}
+	for _, sensor := range sharedNames(before.GetSensors(), after.GetSensors()) {
+		if norm := describe(after, sensor); norm != describe(before, sensor) {
+			res = append(res, SettingDelta{
+				Setting: "normaliser", Layer: -1, Neuron: -1, Sensor: sensor,
+				Before: describe(before, sensor), After: norm,
+			})
+		}
+	}
+	return res
+}
+
+// Same tells whether the nets have the same structure, settings and genes;
+// schemas and metadata are not compared
+func (diff NetDiff) Same() bool {
+	return diff.Comparable && len(diff.Genes) == 0 && len(diff.Settings) == 0 && len(diff.AddedSensors) == 0 &&
+		len(diff.RemovedSensors) == 0 && len(diff.AddedActions) == 0 && len(diff.RemovedActions) == 0
+}
+
+func (diff NetDiff) String() string {
+	var buf strings.Builder
+	for _, change := range []struct {
+		label string
+		names []string
+	}{
+		{"sensors added", diff.AddedSensors},
+		{"sensors removed", diff.RemovedSensors},
+		{"actions added", diff.AddedActions},
+		{"actions removed", diff.RemovedActions},
+	} {
+		if change.names != nil {
+			fmt.Fprintf(&buf, "%v: %v\n", change.label, joinNames(change.names))
+		}
+	}
+	if !sameInts(diff.WidthsBefore, diff.WidthsAfter) {
+		fmt.Fprintf(&buf, "layers: %v -> %v\n", joinInts(diff.WidthsBefore), joinInts(diff.WidthsAfter))
+	}
+	for _, delta := range diff.Settings {
+		fmt.Fprintf(&buf, "%v\n", delta)
+	}
+	if !diff.Comparable {
+		buf.WriteString("genes: not comparable\n")
+		return buf.String()
+	}
+	stats := diff.Stats
+	fmt.Fprintf(&buf, "genes: %v of %v changed", stats.Changed, stats.Compared)
+	if stats.Changed > 0 {
+		fmt.Fprintf(&buf, "; mean %.4g, mean abs %.4g, rms %.4g, max abs %v", stats.Mean, stats.MeanAbs, stats.RMS, stats.MaxAbs)
+	}
+	buf.WriteByte('\n')
+	for _, delta := range diff.Genes {
+		fmt.Fprintf(&buf, "  %v.%v[%v]: %v -> %v (%+d)\n", delta.Layer, delta.Neuron, delta.Gene, delta.Before, delta.After, delta.Delta())
+	}
+	return buf.String()
+}
+
+// BehaviourOptions tunes DiffBehaviour
+type BehaviourOptions struct {
+	// Samples are the readings to compare the nets on; none means Count
+	// random readings
+	Samples []map[string]float64
+	Count   int        // 1000 by default
+	Rand    *rand.Rand // nil means the global math/rand source
+}
+
+// BehaviourDiff reports how the actions shared by two nets differ over
+// samples
+type BehaviourDiff struct {
+	Samples int
+	Actions map[string]ActionDiff
+}
+
+// ActionDiff reports how an action differs over samples
+type ActionDiff struct {
+	Differ    int     // samples where Compute disagrees
+	Rate      float64 // Differ over the samples
+	MeanDelta float64 // mean absolute difference of Activate
+	MaxDelta  float64
+}
+
+// DiffBehaviour compare the outputs of two nets over samples; each net
+// reads its own sensors out of every sample
+func DiffBehaviour(before, after NeuralNet, options BehaviourOptions) (BehaviourDiff, error) {
+	samples := options.Samples
+	if samples == nil {
+		count := options.Count
+		if count <= 0 {
+			count = 1000
+		}
+		samples = SampleInputs(count, options.Rand, before, after)
+	}
+
+	res := BehaviourDiff{Samples: len(samples), Actions: make(map[string]ActionDiff)}
+	shared := sharedNames(before.GetActions(), after.GetActions())
+	for _, action := range shared {
+		res.Actions[action] = ActionDiff{}
+	}
+	for index, sample := range samples {
+		fired := make([]map[string]bool, 2)
+		outputs := make([]map[string]float64, 2)
+		for i, net := range []NeuralNet{before, after} {
+			input := readingsFor(net, sample)
+			var err error
+			if fired[i], err = net.Compute(input); err != nil {
+				return res, fmt.Errorf("sample %v: %w", index, err)
+			}
+			if outputs[i], err = net.Activate(input); err != nil {
+				return res, fmt.Errorf("sample %v: %w", index, err)
+			}
+		}
+		for _, action := range shared {
+			current := res.Actions[action]
+			if fired[0][action] != fired[1][action] {
+				current.Differ++
+			}
+			delta := math.Abs(outputs[1][action] - outputs[0][action])
+			current.MeanDelta += delta
+			current.MaxDelta = math.Max(current.MaxDelta, delta)
+			res.Actions[action] = current
+		}
+	}
+	if len(samples) > 0 {
+		for action, current := range res.Actions {
+			current.Rate = float64(current.Differ) / float64(len(samples))
+			current.MeanDelta /= float64(len(samples))
+			res.Actions[action] = current
+		}
+	}
+	return res, nil
+}
+
+func (diff BehaviourDiff) String() string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "samples: %v\n", diff.Samples)
+	actions := make([]string, 0, len(diff.Actions))
+	for action := range diff.Actions {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	for _, action := range actions {
+		current := diff.Actions[action]
+		fmt.Fprintf(&buf, "%v: differs %v times (%.1f%%); activation delta mean %.4g, max %.4g\n",
+			quoteName(action), current.Differ, 100*current.Rate, current.MeanDelta, current.MaxDelta)
+	}
+	return buf.String()
+}
+
+// SampleInputs draw random readings for the sensors of the nets, uniformly
+// within the range the first net declaring one gives: its schema range,
+// its min-max normaliser range, or two standard deviations around the mean
+// of its standard normaliser; other sensors read within [-1, 1]
+func SampleInputs(count int, rng *rand.Rand, nets ...NeuralNet) []map[string]float64 {
+	var sensors []string
+	for _, net := range nets {
+		sensors = append(sensors, net.GetSensors()...)
+	}
+	sensors = usort(sensors)
+	ranges := make([][2]float64, len(sensors))
+	for i, sensor := range sensors {
+		ranges[i] = sampleRange(nets, sensor)
+	}
+
+	res := make([]map[string]float64, count)
+	for i := range res {
+		res[i] = make(map[string]float64, len(sensors))
+		for j, sensor := range sensors {
+			res[i][sensor] = ranges[j][0] + (ranges[j][1]-ranges[j][0])*float64r(rng)
+		}
+	}
+	return res
+}
+
+func sampleRange(nets []NeuralNet, sensor string) [2]float64 {
+	for _, net := range nets {
+		if field, ok := net.GetSchema()[sensor]; ok && field.HasRange() {
+			return [2]float64{field.Min, field.Max}
+		}
+		if norm, ok := net.GetNormaliser(sensor); ok {
+			switch {
+			case norm.Method == MinMax && norm.Min < norm.Max:
+				return [2]float64{norm.Min, norm.Max}
+			case norm.Method == Standard && norm.Std > 0:
+				return [2]float64{norm.Mean - 2*norm.Std, norm.Mean + 2*norm.Std}
+			}
+		}
+	}
+	return [2]float64{-1, 1}
+}
+
+// readingsFor keep the readings of the sensors of the net
+func readingsFor(net NeuralNet, sample map[string]float64) map[string]float64 {
+	res := make(map[string]float64)
+	for _, sensor := range net.GetSensors() {
+		if value, ok := sample[sensor]; ok {
+			res[sensor] = value
+		}
+	}
+	return res
+}
+
+// setChanges return the sorted names added to and removed from a set
+func setChanges(before, after []string) (added, removed []string) {
+	inBefore := make(map[string]bool)
+	for _, name := range before {
+		inBefore[name] = true
+	}
+	inAfter := make(map[string]bool)
+	for _, name := range after {
+		inAfter[name] = true
+		if !inBefore[name] {
+			added = append(added, name)
+		}
+	}
+	for _, name := range before {
+		if !inAfter[name] {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+func sharedNames(before, after []string) []string {
+	inBefore := make(map[string]bool)
+	for _, name := range before {
+		inBefore[name] = true
+	}
+	var res []string
+	for _, name := range after {
+		if inBefore[name] {
+			res = append(res, name)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// nameIndices return the index in before of each name of after, or -1
+func nameIndices(before, after []string) []int {
+	positions := make(map[string]int)
+	for i, name := range before {
+		positions[name] = i
+	}
+	res := make([]int, len(after))
+	for i, name := range after {
+		if position, ok := positions[name]; ok {
+			res[i] = position
+		} else {
+			res[i] = -1
+		}
+	}
+	return res
+}
+
+func widths(layers [][]Neuron) []int {
+	res := make([]int, len(layers))
+	for i, layer := range layers {
+		res[i] = len(layer)
+	}
+	return res
+}
+
+func sameInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func joinInts(values []int) string {
+	strs := make([]string, len(values))
+	for i, value := range values {
+		strs[i] = fmt.Sprint(value)
+	}
+	return strings.Join(strs, ", ")
+}
@@ -0,0 +1,131 @@
+package neuron
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DotOptions tunes WriteDot
+type DotOptions struct {
+	// Threshold hides every edge whose gene is of absolute value up to it,
+	// so zero genes are never drawn
+	Threshold int
+	// Name is the graph name, "net" by default
+	Name string
+}
+
+// WriteDot draw the net as a Graphviz digraph: sensors on the left, a
+// column per layer and the actions, named, on the right. Edges are blue for
+// positive genes and red for negative ones, wider and more opaque as their
+// magnitude grows; frozen neurons are grey.
+func WriteDot(out io.Writer, net NeuralNet, options DotOptions) error {
+	name := options.Name
+	if name == "" {
+		name = "net"
+	}
+	layers := netLayers(net)
+	sensors := net.GetSensors()
+	actions := net.GetActions()
+	strongest := strongestGene(layers, options.Threshold)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "digraph %v {\n", dotQuote(name))
+	buf.WriteString("\trankdir=LR;\n\tsplines=line;\n\tnode [shape=circle, fixedsize=true, width=0.6, fontsize=10];\n")
+
+	buf.WriteString("\n\t{\n\t\trank=same;\n")
+	for i, sensor := range sensors {
+		fmt.Fprintf(&buf, "\t\ts%v [label=%v, shape=box, fixedsize=false];\n", i, dotQuote(sensor))
+	}
+	buf.WriteString("\t}\n")
+	for i, layer := range layers {
+		last := i == len(layers)-1
+		fmt.Fprintf(&buf, "\n\t{\n\t\trank=same;\n\t\t// layer %v, %v\n", i, net.GetActivation(i))
+		for j := range layer {
+			label := fmt.Sprintf("%v.%v", i, j)
+			attrs := ""
+			if last {
+				label = actions[j]
+				attrs = ", shape=doublecircle, fixedsize=false"
+			}
+			if net.IsFrozen(i, j) {
+				attrs += ", style=filled, fillcolor=lightgrey"
+			}
+			fmt.Fprintf(&buf, "\t\tn%v_%v [label=%v%v];\n", i, j, dotQuote(label), attrs)
+		}
+		buf.WriteString("\t}\n")
+	}
+
+	buf.WriteByte('\n')
+	for i, layer := range layers {
+		for j, neu := range layer {
+			for k := 0; k < neu.GetSize(); k++ {
+				gene := neu.GetGene(k)
+				if abs(gene) <= options.Threshold {
+					continue
+				}
+				source := fmt.Sprintf("s%v", k)
+				if i > 0 {
+					source = fmt.Sprintf("n%v_%v", i-1, k)
+				}
+				colour, width := edgeStyle(gene, strongest)
+				fmt.Fprintf(&buf, "\t%v -> n%v_%v [color=\"%v\", penwidth=%.2f, tooltip=\"%v\"];\n",
+					source, i, j, colour, width, gene)
+			}
+		}
+	}
+	buf.WriteString("}\n")
+
+	_, err := out.Write(buf.Bytes())
+	return err
+}
+
+// edgeStyle return the colour, alpha included, and the width of the edge of
+// a gene, relative to the strongest gene drawn
+func edgeStyle(gene, strongest int) (string, float64) {
+	edge := renderEdge{gene: gene, weight: float64(abs(gene)) / float64(strongest)}
+	colour := edgeColour(edge)
+	return fmt.Sprintf("%v%02x", hexColour(colour), colour.A), 0.5 + 4.5*edge.weight
+}
+
+// strongestGene return the largest absolute gene above the threshold, at
+// least 1
+func strongestGene(layers [][]Neuron, threshold int) int {
+	res := 1
+	for _, layer := range layers {
+		for _, neu := range layer {
+			for k := 0; k < neu.GetSize(); k++ {
+				if gene := abs(neu.GetGene(k)); gene > threshold && gene > res {
+					res = gene
+				}
+			}
+		}
+	}
+	return res
+}
+
+// netLayers return every layer of neurons of any net
+func netLayers(net NeuralNet) [][]Neuron {
+	var res [][]Neuron
+	for i := 0; ; i++ {
+		layer := net.GetNeurons(i)
+		if layer == nil {
+			return res
+		}
+		res = append(res, layer)
+	}
+}
+
+// dotQuote quote a Graphviz string, escaping what labels would interpret
+func dotQuote(str string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", "")
+	return `"` + replacer.Replace(str) + `"`
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
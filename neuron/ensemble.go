@@ -0,0 +1,281 @@
+package neuron
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// Voting tells how an ensemble combines the decisions of its members
+type Voting uint8
+
+const (
+	// Majority triggers actions triggered by more than half the members
+	Majority Voting = iota
+	// Weighted triggers actions triggered by more than half the total weight
+	Weighted
+	// Unanimous triggers actions triggered by every member
+	Unanimous
+	// Average triggers actions whose weighted mean activation fires
+	Average
+)
+
+var votingNames = []string{"majority", "weighted", "unanimous", "average"}
+
+func (voting Voting) String() string {
+	if int(voting) < len(votingNames) {
+		return votingNames[voting]
+	}
+	return fmt.Sprintf("voting(%d)", uint8(voting))
+}
+
+// Ensemble represents neural nets deciding together
+type Ensemble interface {
+	GetActions() []string
+	GetChild(int) Ensemble
+	GetSensors() []string
+	GetMembers() []NeuralNet
+	GetWeights() []float64
+	GetVoting() Voting
+	Compute(map[string]float64) (map[string]bool, error)
+	Activate(map[string]float64) (map[string]float64, error)
+	Save(io.Writer) error
+	String() string
+}
+
+type ensemble struct {
+	members []NeuralNet
+	weights []float64
+	voting  Voting
+}
+
+var ensembleMagic = []byte("ENSM")
+
+// NewEnsemble instantiate a new ensemble of nets sharing sensors and
+// actions; nil weights mean every member weighs 1
+func NewEnsemble(voting Voting, members []NeuralNet, weights []float64) (Ensemble, error) {
+	if int(voting) >= len(votingNames) {
+		return nil, fmt.Errorf("invalid voting %v", voting)
+	}
+	if len(members) == 0 {
+		return nil, fmt.Errorf("no member supplied")
+	}
+	if weights == nil {
+		weights = make([]float64, len(members))
+		for i := range weights {
+			weights[i] = 1
+		}
+	}
+	if len(weights) != len(members) {
+		return nil, fmt.Errorf("expected %v weights, got %v", len(members), len(weights))
+	}
+
+	sensors := strings.Join(members[0].GetSensors(), "\x00")
+	actions := strings.Join(members[0].GetActions(), "\x00")
+	activation := lastActivation(members[0])
+	for i, member := range members {
+		if strings.Join(member.GetSensors(), "\x00") != sensors {
+			return nil, fmt.Errorf("member %v: sensors mismatch", i)
+		}
+		if strings.Join(member.GetActions(), "\x00") != actions {
+			return nil, fmt.Errorf("member %v: actions mismatch", i)
+		}
+		if voting == Average && lastActivation(member) != activation {
+			return nil, fmt.Errorf("member %v: output activation mismatch", i)
+		}
+		if weights[i] < 0 || math.IsNaN(weights[i]) || math.IsInf(weights[i], 0) {
+			return nil, fmt.Errorf("member %v: invalid weight %v", i, weights[i])
+		}
+	}
+
+	res := &ensemble{
+		members: make([]NeuralNet, len(members)),
+		weights: make([]float64, len(weights)),
+		voting:  voting,
+	}
+	copy(res.members, members)
+	copy(res.weights, weights)
+	return res, nil
+}
+
+// LoadEnsemble load an ensemble from an I/O reader
+func LoadEnsemble(input io.Reader) (Ensemble, error) {
+	var head [9]byte
+	if _, err := io.ReadFull(input, head[:4]); err == io.EOF {
+		return nil, err
+	} else if err != nil {
+		return nil, decodeError("ensemble header", err)
+	}
+	if !bytes.Equal(head[:4], ensembleMagic) {
+		return nil, ErrUnknownFormat
+	}
+	if _, err := io.ReadFull(input, head[4:]); err != nil {
+		return nil, decodeError("ensemble header", err)
+	}
+	voting := Voting(head[4])
+	if int(voting) >= len(votingNames) {
+		return nil, malformed("invalid voting %v", head[4])
+	}
+	size := int(binary.BigEndian.Uint32(head[5:]))
+
+	var members []NeuralNet
+	var weights []float64
+	for i := 0; i < size; i++ {
+		var current [8]byte
+		if _, err := io.ReadFull(input, current[:]); err != nil {
+			return nil, decodeError(fmt.Sprintf("member %v weight", i), err)
+		}
+		weights = append(weights, math.Float64frombits(binary.BigEndian.Uint64(current[:])))
+		member, err := LoadNet(input)
+		if err == io.EOF {
+			err = decodeError("header", err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("member %v: %w", i, err)
+		}
+		members = append(members, member)
+	}
+	return NewEnsemble(voting, members, weights)
+}
+
+func (ens ensemble) GetActions() []string {
+	return ens.members[0].GetActions()
+}
+
+func (ens ensemble) GetSensors() []string {
+	return ens.members[0].GetSensors()
+}
+
+func (ens ensemble) GetMembers() []NeuralNet {
+	members := make([]NeuralNet, len(ens.members))
+	copy(members, ens.members)
+	return members
+}
+
+func (ens ensemble) GetWeights() []float64 {
+	weights := make([]float64, len(ens.weights))
+	copy(weights, ens.weights)
+	return weights
+}
+
+func (ens ensemble) GetVoting() Voting {
+	return ens.voting
+}
+
+func (ens ensemble) GetChild(dev int) Ensemble {
+	members := make([]NeuralNet, len(ens.members))
+	for i, member := range ens.members {
+		members[i] = member.GetChild(dev)
+	}
+	return &ensemble{members, ens.weights, ens.voting}
+}
+
+func (ens ensemble) Compute(incoming map[string]float64) (map[string]bool, error) {
+	if ens.voting == Average {
+		activations, err := ens.Activate(incoming)
+		if err != nil {
+			return nil, err
+		}
+		activation := lastActivation(ens.members[0])
+		res := make(map[string]bool)
+		for action, value := range activations {
+			res[action] = activation.fires(value)
+		}
+		return res, nil
+	}
+
+	votes := make(map[string]float64)
+	total := 0.0
+	for i, member := range ens.members {
+		weight := 1.0
+		if ens.voting == Weighted {
+			weight = ens.weights[i]
+		}
+		total += weight
+		decisions, err := member.Compute(incoming)
+		if err != nil {
+			return nil, err
+		}
+		for action, decision := range decisions {
+			if decision {
+				votes[action] += weight
+			}
+		}
+	}
+
+	res := make(map[string]bool)
+	for _, action := range ens.GetActions() {
+		if ens.voting == Unanimous {
+			res[action] = votes[action] == total
+		} else {
+			res[action] = votes[action] > total/2
+		}
+	}
+	return res, nil
+}
+
+// Activate return the weighted mean of the members’ activations
+func (ens ensemble) Activate(incoming map[string]float64) (map[string]float64, error) {
+	res := make(map[string]float64)
+	total := 0.0
+	for i, member := range ens.members {
+		activations, err := member.Activate(incoming)
+		if err != nil {
+			return nil, err
+		}
+		for action, value := range activations {
+			res[action] += value * ens.weights[i]
+		}
+		total += ens.weights[i]
+	}
+	for action := range res {
+		if total > 0 {
+			res[action] /= total
+		} else {
+			res[action] = 0
+		}
+	}
+	return res, nil
+}
+
+func (ens ensemble) String() string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "ENSEMBLE: %v\n", ens.voting)
+	for i, member := range ens.members {
+		fmt.Fprintf(&buf, "MEMBER %v: %v\n", i, ens.weights[i])
+		buf.WriteString(member.String())
+	}
+	return buf.String()
+}
+
+func (ens ensemble) Save(out io.Writer) error {
+	var buf bytes.Buffer
+	var current [8]byte
+
+	buf.Write(ensembleMagic)
+	buf.WriteByte(byte(ens.voting))
+	binary.BigEndian.PutUint32(current[:], uint32(len(ens.members)))
+	buf.Write(current[:4])
+
+	for i, member := range ens.members {
+		binary.BigEndian.PutUint64(current[:], math.Float64bits(ens.weights[i]))
+		buf.Write(current[:])
+		if err := member.Save(&buf); err != nil {
+			return fmt.Errorf("member %v: %v", i, err)
+		}
+	}
+
+	_, err := out.Write(buf.Bytes())
+	return err
+}
+
+func lastActivation(net NeuralNet) Activation {
+	index := 0
+	for net.GetNeurons(index+1) != nil {
+		index++
+	}
+	return net.GetActivation(index)
+}
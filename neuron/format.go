@@ -0,0 +1,345 @@
+package neuron
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+)
+
+// Format identifies a layout of saved nets
+type Format uint16
+
+const (
+	// Legacy is the original layout: a uint16 size, sensors, actions and
+	// neurons, with no magic nor checksum
+	Legacy Format = 1
+	// V2 is a container starting with magic bytes and a format version,
+	// holding length-prefixed sections checked by a CRC32
+	V2 Format = 2
+)
+
+// CurrentFormat is the format written by Save
+const CurrentFormat = V2
+
+var (
+	// ErrUnknownFormat is returned when loading data that is no saved net
+	ErrUnknownFormat = errors.New("unknown net format")
+	// ErrChecksum is returned when a saved net fails its integrity check
+	ErrChecksum = errors.New("net checksum mismatch")
+	// ErrTruncated is wrapped by decode errors when the input ends early
+	ErrTruncated = errors.New("truncated input")
+	// ErrMalformed is wrapped by decode errors when the input is inconsistent
+	ErrMalformed = errors.New("malformed input")
+)
+
+// DecodeError reports a saved net or neuron that cannot be decoded; Err is
+// ErrTruncated, ErrMalformed or the error of the underlying reader
+type DecodeError struct {
+	What string
+	Err  error
+}
+
+func (err *DecodeError) Error() string {
+	return fmt.Sprintf("%v: %v", err.What, err.Err)
+}
+
+func (err *DecodeError) Unwrap() error {
+	return err.Err
+}
+
+// decodeError wrap a read error, reporting early ends as truncation
+func decodeError(what string, err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = ErrTruncated
+	}
+	return &DecodeError{What: what, Err: err}
+}
+
+func malformed(what string, args ...interface{}) error {
+	return &DecodeError{What: fmt.Sprintf(what, args...), Err: ErrMalformed}
+}
+
+// OverflowError is returned when saving a value too big for its field in
+// the chosen format
+type OverflowError struct {
+	Field string
+	Value uint64
+	Max   uint64
+}
+
+func (err *OverflowError) Error() string {
+	return fmt.Sprintf("%v: %v exceeds the format limit of %v", err.Field, err.Value, err.Max)
+}
+
+// putLegacyCount write a uint16 count into the first half of a legacy field
+func putLegacyCount(field []byte, name string, value int) error {
+	if value > math.MaxUint16 {
+		return &OverflowError{Field: name, Value: uint64(value), Max: math.MaxUint16}
+	}
+	binary.BigEndian.PutUint16(field, uint16(value))
+	return nil
+}
+
+// A V2 file is laid out as:
+//
+//	magic    "\x89NRN"
+//	version  uint16
+//	length   uint32, payload size
+//	payload  sections: four-byte tag, uint32 body length, body
+//	checksum uint32, CRC32 (IEEE) of the payload
+//
+// The payload holds the SENS, ACTN and LAYR sections, followed by the
+// optional ones. Legacy files never match the magic, since their third and
+// fourth bytes are zero.
+var magic = [4]byte{0x89, 'N', 'R', 'N'}
+
+func (net neuralnet) saveV2(out io.Writer) error {
+	data, err := net.encodeV2()
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(data)
+	return err
+}
+
+// encodeV2 return the net in the V2 format, the payload being written in
+// place between its header and checksum
+func (net neuralnet) encodeV2() ([]byte, error) {
+	if err := checkGenes(net.neurons); err != nil {
+		return nil, err
+	}
+	layers := layersSize(net.neurons)
+	var buf bytes.Buffer
+	buf.Grow(256 + 8*(len(net.sensors)+len(net.actions)) + int(layers))
+
+	var current [4]byte
+	buf.Write(magic[:])
+	binary.BigEndian.PutUint16(current[:], uint16(V2))
+	buf.Write(current[:2])
+	buf.Write(current[:]) // payload length, set once known
+	writeSection(&buf, "SENS", encodeStrings(net.sensors))
+	writeSection(&buf, "ACTN", encodeStrings(net.actions))
+	if layers > math.MaxUint32 {
+		return nil, &OverflowError{Field: "layers", Value: layers, Max: math.MaxUint32}
+	}
+	buf.WriteString("LAYR")
+	binary.BigEndian.PutUint32(current[:], uint32(layers))
+	buf.Write(current[:])
+	writeLayers(&buf, net.neurons)
+	net.writeSections(&buf)
+
+	data := buf.Bytes()
+	payload := data[10:]
+	if uint64(len(payload)) > math.MaxUint32 {
+		return nil, &OverflowError{Field: "payload", Value: uint64(len(payload)), Max: math.MaxUint32}
+	}
+	binary.BigEndian.PutUint32(data[6:], uint32(len(payload)))
+	binary.BigEndian.PutUint32(current[:], crc32.ChecksumIEEE(payload))
+	buf.Write(current[:])
+	return buf.Bytes(), nil
+}
+
+// loadV2 read a V2 net whose magic bytes were already consumed
+func loadV2(input io.Reader) (NeuralNet, error) {
+	var head [6]byte
+	if _, err := io.ReadFull(input, head[:]); err != nil {
+		return nil, decodeError("header", err)
+	}
+	if version := Format(binary.BigEndian.Uint16(head[:])); version != V2 {
+		return nil, malformed("unsupported format version %v", version)
+	}
+
+	// Copy rather than allocate the whole announced length up front
+	payload, err := readBody(input, binary.BigEndian.Uint32(head[2:]))
+	if err != nil {
+		return nil, decodeError("payload", err)
+	}
+	sum, err := readUint32(input)
+	if err != nil {
+		return nil, decodeError("checksum", err)
+	}
+	if sum != crc32.ChecksumIEEE(payload) {
+		return nil, ErrChecksum
+	}
+
+	var sensors, actions []string
+	var neurons []Layer
+	var options []Option
+	seen := make(map[string]bool)
+	body := payload
+	for len(body) > 0 {
+		if len(body) < 8 {
+			return nil, &DecodeError{What: "section header", Err: ErrTruncated}
+		}
+		tag := string(body[:4])
+		size := binary.BigEndian.Uint32(body[4:])
+		body = body[8:]
+		if uint64(size) > uint64(len(body)) {
+			return nil, &DecodeError{What: fmt.Sprintf("section %q", tag), Err: ErrTruncated}
+		}
+		current := body[:size]
+		body = body[size:]
+
+		if seen[tag] {
+			return nil, malformed("section %q repeated", tag)
+		}
+		seen[tag] = true
+
+		switch tag {
+		case "SENS":
+			sensors, err = decodeStrings(current)
+		case "ACTN":
+			actions, err = decodeStrings(current)
+		case "LAYR":
+			neurons, err = decodeLayers(current)
+		default:
+			var option Option
+			if option, err = sectionOption(tag, current); option != nil {
+				options = append(options, option)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, tag := range []string{"SENS", "ACTN", "LAYR"} {
+		if !seen[tag] {
+			return nil, malformed("section %q missing", tag)
+		}
+	}
+
+	return buildNet(sensors, actions, neurons, options)
+}
+
+// buildNet put a decoded net together, reporting the nets and options
+// NewNeuralNet rejects as malformed input
+func buildNet(sensors, actions []string, neurons []Layer, options []Option) (NeuralNet, error) {
+	net, err := NewNeuralNet(sensors, actions, neurons, options...)
+	if err != nil {
+		return nil, malformed("%v", err)
+	}
+	return net, nil
+}
+
+func encodeStrings(values []string) []byte {
+	var buf bytes.Buffer
+	var current [4]byte
+	binary.BigEndian.PutUint32(current[:], uint32(len(values)))
+	buf.Write(current[:])
+	for _, value := range values {
+		binary.BigEndian.PutUint32(current[:], uint32(len(value)))
+		buf.Write(current[:])
+		buf.WriteString(value)
+	}
+	return buf.Bytes()
+}
+
+func decodeStrings(body []byte) ([]string, error) {
+	input := bytes.NewReader(body)
+	count, err := readUint32(input)
+	if err != nil {
+		return nil, decodeError("string count", err)
+	}
+	if uint64(count) > uint64(input.Len()/4) {
+		return nil, malformed("%v strings in %v bytes", count, input.Len())
+	}
+	res := make([]string, count)
+	for i := range res {
+		size, err := readUint32(input)
+		if err != nil {
+			return nil, decodeError("string size", err)
+		}
+		if uint64(size) > uint64(input.Len()) {
+			return nil, &DecodeError{What: "string", Err: ErrTruncated}
+		}
+		value := make([]byte, size)
+		input.Read(value)
+		res[i] = string(value)
+	}
+	if input.Len() > 0 {
+		return nil, malformed("%v trailing bytes after strings", input.Len())
+	}
+	return res, nil
+}
+
+// layersSize return the length of the LAYR section body
+func layersSize(layers []Layer) uint64 {
+	size := uint64(4)
+	for _, layer := range layers {
+		size += 4
+		for _, neu := range layer {
+			size += 4 + 4*uint64(neu.GetSize())
+		}
+	}
+	return size
+}
+
+func writeLayers(buf *bytes.Buffer, layers []Layer) {
+	var current [4]byte
+	binary.BigEndian.PutUint32(current[:], uint32(len(layers)))
+	buf.Write(current[:])
+	for _, layer := range layers {
+		binary.BigEndian.PutUint32(current[:], uint32(len(layer)))
+		buf.Write(current[:])
+		for _, neu := range layer {
+			binary.BigEndian.PutUint32(current[:], uint32(neu.GetSize()))
+			buf.Write(current[:])
+			for i := 0; i < neu.GetSize(); i++ {
+				binary.BigEndian.PutUint32(current[:], uint32(int32(neu.GetGene(i))))
+				buf.Write(current[:])
+			}
+		}
+	}
+}
+
+func decodeLayers(body []byte) ([]Layer, error) {
+	input := bytes.NewReader(body)
+	count, err := readUint32(input)
+	if err != nil {
+		return nil, decodeError("layer count", err)
+	}
+	if uint64(count) > uint64(input.Len()/4) {
+		return nil, malformed("%v layers in %v bytes", count, input.Len())
+	}
+	res := make([]Layer, count)
+	for i := range res {
+		width, err := readUint32(input)
+		if err != nil {
+			return nil, decodeError(fmt.Sprintf("layer %v width", i), err)
+		}
+		if uint64(width) > uint64(input.Len()/4) {
+			return nil, malformed("layer %v: %v neurons in %v bytes", i, width, input.Len())
+		}
+		res[i] = make(Layer, width)
+		for j := range res[i] {
+			size, err := readUint32(input)
+			if err != nil {
+				return nil, decodeError(fmt.Sprintf("layer %v, neuron %v size", i, j), err)
+			}
+			if uint64(size) > uint64(input.Len()/4) {
+				return nil, &DecodeError{What: fmt.Sprintf("layer %v, neuron %v genes", i, j), Err: ErrTruncated}
+			}
+			offset := len(body) - input.Len()
+			res[i][j] = appendGenes(make(neuron, 0, size), body[offset:offset+4*int(size)])
+			input.Seek(int64(4*size), io.SeekCurrent)
+		}
+	}
+	if input.Len() > 0 {
+		return nil, malformed("%v trailing bytes after layers", input.Len())
+	}
+	return res, nil
+}
+
+// readBody read size bytes, growing the buffer as data arrives rather than
+// trusting the announced size up front
+func readBody(input io.Reader, size uint32) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, input, int64(size)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,217 @@
+package neuron
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// GeneScale is how many gene units the statistical initialisers (Xavier, He
+// and Orthogonal) use to represent a unit weight
+const GeneScale = 1000
+
+// Initialiser draws the genes of a new layer holding width neurons of size
+// genes each; a nil rng means the global math/rand source
+type Initialiser func(rng *rand.Rand, width, size int) [][]int
+
+// NeuronSpec describes a random neuron to be created by NewNeuron
+type NeuronSpec struct {
+	Size        int
+	FanOut      int // neurons in the same layer, 1 if zero
+	Initialiser Initialiser
+	Rand        *rand.Rand
+}
+
+// Uniform draw genes uniformly from [min, max); an empty range draws no
+// genes at all, which BuildNet and NewNeuron report as an error
+func Uniform(min, max int) Initialiser {
+	return func(rng *rand.Rand, width, size int) [][]int {
+		if max <= min {
+			return nil
+		}
+		// Unsigned, the span of any int range fits
+		span := uint64(max) - uint64(min)
+		return fill(width, size, func() int {
+			return int(uint64(min) + uint64n(rng, span))
+		})
+	}
+}
+
+// Constant set every gene to value
+func Constant(value int) Initialiser {
+	return func(rng *rand.Rand, width, size int) [][]int {
+		return fill(width, size, func() int { return value })
+	}
+}
+
+// Gaussian draw genes from a normal distribution centred on zero with
+// standard deviation std, given in gene units
+func Gaussian(std float64) Initialiser {
+	return func(rng *rand.Rand, width, size int) [][]int {
+		return fill(width, size, func() int {
+			return int(math.Round(normFloat64(rng) * std))
+		})
+	}
+}
+
+// Xavier draw genes according to Glorot initialisation, which keeps the
+// variance steady across layers of symmetric activations
+func Xavier() Initialiser {
+	return func(rng *rand.Rand, width, size int) [][]int {
+		std := GeneScale * math.Sqrt(2/float64(width+size))
+		return Gaussian(std)(rng, width, size)
+	}
+}
+
+// He draw genes according to He initialisation, suited to ReLU layers
+func He() Initialiser {
+	return func(rng *rand.Rand, width, size int) [][]int {
+		if size == 0 {
+			return fill(width, 0, nil)
+		}
+		std := GeneScale * math.Sqrt(2/float64(size))
+		return Gaussian(std)(rng, width, size)
+	}
+}
+
+// Orthogonal draw a random layer whose neurons (or genes, when there are
+// more neurons than genes) are orthogonal vectors of length gain
+func Orthogonal(gain float64) Initialiser {
+	return func(rng *rand.Rand, width, size int) [][]int {
+		rows, cols := width, size
+		if rows > cols {
+			rows, cols = cols, rows
+		}
+
+		matrix := make([][]float64, rows)
+		for i := range matrix {
+			matrix[i] = make([]float64, cols)
+			for j := range matrix[i] {
+				matrix[i][j] = normFloat64(rng)
+			}
+		}
+		// Gram-Schmidt
+		for i, row := range matrix {
+			for _, previous := range matrix[:i] {
+				dot := 0.0
+				for k := range row {
+					dot += row[k] * previous[k]
+				}
+				for k := range row {
+					row[k] -= dot * previous[k]
+				}
+			}
+			norm := 0.0
+			for _, value := range row {
+				norm += value * value
+			}
+			norm = math.Sqrt(norm)
+			if norm == 0 {
+				continue
+			}
+			for k := range row {
+				row[k] /= norm
+			}
+		}
+
+		res := fill(width, size, func() int { return 0 })
+		for i, row := range matrix {
+			for j, value := range row {
+				gene := int(math.Round(value * gain * GeneScale))
+				if width > size {
+					res[j][i] = gene
+				} else {
+					res[i][j] = gene
+				}
+			}
+		}
+		return res
+	}
+}
+
+func defaultInitialiser(rng *rand.Rand, width, size int) [][]int {
+	return fill(width, size, func() int {
+		return int(int31n(rng, 2000)) - 1000
+	})
+}
+
+func newRandomNeuron(spec NeuronSpec) (Neuron, error) {
+	if spec.Size < 0 {
+		return nil, fmt.Errorf("negative neuron size %v", spec.Size)
+	}
+	if spec.FanOut < 0 {
+		return nil, fmt.Errorf("negative fan-out %v", spec.FanOut)
+	}
+	fanOut := spec.FanOut
+	if fanOut == 0 {
+		fanOut = 1
+	}
+	initialiser := spec.Initialiser
+	if initialiser == nil {
+		initialiser = defaultInitialiser
+	}
+	genes := initialiser(spec.Rand, fanOut, spec.Size)
+	if len(genes) == 0 || len(genes[0]) != spec.Size {
+		return nil, fmt.Errorf("initialiser returned unexpected genes for size %v", spec.Size)
+	}
+	return neuron(genes[0]), nil
+}
+
+func fill(width, size int, gene func() int) [][]int {
+	res := make([][]int, width)
+	for i := range res {
+		genes := make([]int, size)
+		for j := range genes {
+			genes[j] = gene()
+		}
+		res[i] = genes
+	}
+	return res
+}
+
+func int31n(rng *rand.Rand, n int32) int32 {
+	if rng == nil {
+		return rand.Int31n(n)
+	}
+	return rng.Int31n(n)
+}
+
+func int63n(rng *rand.Rand, n int64) int64 {
+	if rng == nil {
+		return rand.Int63n(n)
+	}
+	return rng.Int63n(n)
+}
+
+// uint64n draw uniformly from [0, n), n being above zero
+func uint64n(rng *rand.Rand, n uint64) uint64 {
+	if n <= math.MaxInt64 {
+		return uint64(int63n(rng, int64(n)))
+	}
+	// Rejection keeps the draw uniform, accepting over half the values
+	for {
+		var value uint64
+		if rng == nil {
+			value = rand.Uint64()
+		} else {
+			value = rng.Uint64()
+		}
+		if value < n {
+			return value
+		}
+	}
+}
+
+func normFloat64(rng *rand.Rand) float64 {
+	if rng == nil {
+		return rand.NormFloat64()
+	}
+	return rng.NormFloat64()
+}
+
+func float64r(rng *rand.Rand) float64 {
+	if rng == nil {
+		return rand.Float64()
+	}
+	return rng.Float64()
+}
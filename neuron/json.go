@@ -0,0 +1,161 @@
+package neuron
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// netJSON is the JSON document of a net: sensors, actions and layers of
+// gene arrays, followed by the settings the net declares
+type netJSON struct {
+	Sensors     []string              `json:"sensors"`
+	Actions     []string              `json:"actions"`
+	Layers      [][][]int             `json:"layers"`
+	Activations []Activation          `json:"activations,omitempty"`
+	Bounds      []Bounds              `json:"bounds,omitempty"`
+	Normalisers map[string]Normaliser `json:"normalisers,omitempty"`
+	Schema      Schema                `json:"schema,omitempty"`
+	Frozen      []NeuronRef           `json:"frozen,omitempty"`
+	Parts       []partJSON            `json:"parts,omitempty"`
+	Metadata    Metadata              `json:"metadata,omitempty"`
+}
+
+type partJSON struct {
+	Sensors []string `json:"sensors"`
+	Actions []string `json:"actions"`
+	Widths  []int    `json:"widths"`
+}
+
+func (net neuralnet) MarshalJSON() ([]byte, error) {
+	doc := netJSON{
+		Sensors:     net.sensors,
+		Actions:     net.actions,
+		Layers:      make([][][]int, len(net.neurons)),
+		Bounds:      net.bounds,
+		Normalisers: net.normalisers,
+		Schema:      net.schema,
+		Metadata:    net.metadata,
+	}
+	for i, layer := range net.neurons {
+		doc.Layers[i] = make([][]int, len(layer))
+		for j, neu := range layer {
+			doc.Layers[i][j] = genes(neu)
+		}
+	}
+	if net.customActivations() {
+		doc.Activations = net.activations
+	}
+	for i, flags := range net.frozen {
+		for j, flag := range flags {
+			if flag {
+				doc.Frozen = append(doc.Frozen, NeuronRef{Layer: i, Neuron: j})
+			}
+		}
+	}
+	for _, current := range net.parts {
+		doc.Parts = append(doc.Parts, partJSON{current.sensors, current.actions, current.widths})
+	}
+	return json.Marshal(doc)
+}
+
+// UnmarshalJSON decode a JSON document into the value, checking it through
+// NewNeuralNet
+func (value *NetValue) UnmarshalJSON(data []byte) error {
+	var doc netJSON
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&doc); err != nil {
+		return err
+	}
+
+	neurons := make([]Layer, len(doc.Layers))
+	for i, layer := range doc.Layers {
+		neurons[i] = make(Layer, len(layer))
+		for j, genes := range layer {
+			if k := outOfRange(genes); k >= 0 {
+				return &GeneRangeError{Layer: i, Neuron: j, Gene: k, Value: genes[k], Bounds: DefaultBounds}
+			}
+			neurons[i][j] = neuron(genes)
+		}
+	}
+	var options []Option
+	if doc.Activations != nil {
+		options = append(options, WithActivations(doc.Activations...))
+	}
+	if doc.Bounds != nil {
+		options = append(options, WithBounds(doc.Bounds...))
+	}
+	if doc.Normalisers != nil {
+		options = append(options, WithNormalisers(doc.Normalisers))
+	}
+	if doc.Schema != nil {
+		options = append(options, WithSchema(doc.Schema))
+	}
+	if doc.Frozen != nil {
+		options = append(options, WithFrozenNeurons(doc.Frozen...))
+	}
+	if doc.Parts != nil {
+		parts := make([]part, len(doc.Parts))
+		for i, current := range doc.Parts {
+			parts[i] = part{current.Sensors, current.Actions, current.Widths}
+		}
+		options = append(options, withParts(parts))
+	}
+	if doc.Metadata != nil {
+		options = append(options, WithMetadata(doc.Metadata))
+	}
+
+	net, err := NewNeuralNet(doc.Sensors, doc.Actions, neurons, options...)
+	if err != nil {
+		return fmt.Errorf("invalid net: %w", err)
+	}
+	value.NeuralNet = net
+	return nil
+}
+
+func (neu neuron) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]int(neu))
+}
+
+func (neu *neuron) UnmarshalJSON(data []byte) error {
+	var genes []int
+	if err := json.Unmarshal(data, &genes); err != nil {
+		return err
+	}
+	if k := outOfRange(genes); k >= 0 {
+		return fmt.Errorf("gene %v: value %v out of range [%v, %v]", k, genes[k], MinGene, MaxGene)
+	}
+	*neu = genes
+	return nil
+}
+
+// UnmarshalJSON decode an array of genes into the value
+func (value *NeuronValue) UnmarshalJSON(data []byte) error {
+	var neu neuron
+	if err := neu.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	value.Neuron = neu
+	return nil
+}
+
+// outOfRange return the index of the first gene the file format cannot
+// hold, or -1
+func outOfRange(genes []int) int {
+	for i, gene := range genes {
+		if !DefaultBounds.Contains(gene) {
+			return i
+		}
+	}
+	return -1
+}
+
+// genes return the genes of any neuron
+func genes(neu Neuron) []int {
+	res := make([]int, neu.GetSize())
+	for i := range res {
+		res[i] = neu.GetGene(i)
+	}
+	return res
+}
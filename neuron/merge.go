@@ -0,0 +1,461 @@
+package neuron
+
+import "fmt"
+
+// A merged net lays its parts side by side. Each hidden layer holds one
+// contiguous block of neurons per part, in part order, reading only from the
+// block of the same part in the previous layer (or from the part’s sensors).
+// Parts shallower than the merged net compute their actions early and pass
+// them on through identity neurons down to the last layer, whose neurons
+// follow the sorted actions.
+type part struct {
+	sensors []string
+	actions []string
+	widths  []int // neurons on each layer of the part, the last one being its actions
+}
+
+type layout struct {
+	depth   int
+	offsets [][]int // [layer][part] first neuron of the part’s block, last layer excluded
+	blocks  [][]int // [layer][part] neurons in the part’s block, last layer excluded
+	sensors [][]int // [part] positions of the part’s sensors
+	actions [][]int // [part] positions of the part’s actions on the last layer
+}
+
+// Merge put several nets side by side into one, reading the union of their
+// sensors and triggering the union of their actions
+func Merge(nets ...NeuralNet) (NeuralNet, error) {
+	if len(nets) < 2 {
+		return nil, fmt.Errorf("expected at least 2 nets, got %v", len(nets))
+	}
+
+	bases := make([]*neuralnet, len(nets))
+	parts := make([]part, len(nets))
+	var sensors, actions []string
+	owners := make(map[string]int)
+	depth := 0
+	for p, net := range nets {
+		base, err := asNeuralNet(net)
+		if err != nil {
+			return nil, err
+		}
+		bases[p] = base
+		widths := make([]int, len(base.neurons))
+		for i, layer := range base.neurons {
+			widths[i] = len(layer)
+		}
+		parts[p] = part{base.GetSensors(), base.GetActions(), widths}
+		sensors = append(sensors, base.sensors...)
+		for _, action := range base.actions {
+			if owner, ok := owners[action]; ok {
+				return nil, fmt.Errorf("action %v on both nets %v and %v", action, owner, p)
+			}
+			owners[action] = p
+			actions = append(actions, action)
+		}
+		if len(widths) > depth {
+			depth = len(widths)
+		}
+	}
+	sensors = usort(sensors)
+	actions = usort(actions)
+
+	activations := make([]Activation, depth)
+	bounds := make([]Bounds, depth)
+	customBounds := false
+	for i := 0; i < depth; i++ {
+		first := true
+		for p, base := range bases {
+			if i >= len(base.neurons) {
+				continue
+			}
+			customBounds = customBounds || base.bounds != nil
+			if first {
+				activations[i], bounds[i] = base.GetActivation(i), base.GetBounds(i)
+				first = false
+				continue
+			}
+			if base.GetActivation(i) != activations[i] {
+				return nil, fmt.Errorf("net %v, layer %v: activation mismatch", p, i)
+			}
+			if base.GetBounds(i) != bounds[i] {
+				return nil, fmt.Errorf("net %v, layer %v: bounds mismatch", p, i)
+			}
+		}
+	}
+	for p, base := range bases {
+		output := base.GetActivation(len(base.neurons) - 1)
+		for i := len(base.neurons); i < depth; i++ {
+			if !passesThrough(output, activations[i]) {
+				return nil, fmt.Errorf("net %v: %v outputs cannot pass through %v layer %v", p, output, activations[i], i)
+			}
+			if !bounds[i].Contains(0) || !bounds[i].Contains(1) {
+				return nil, fmt.Errorf("net %v: bounds of layer %v forbid pass-through genes", p, i)
+			}
+		}
+	}
+
+	normalisers := make(map[string]Normaliser)
+	schema := make(Schema)
+	for p, base := range bases {
+		for sensor, norm := range base.normalisers {
+			if other, ok := normalisers[sensor]; ok && other != norm {
+				return nil, fmt.Errorf("net %v: normaliser mismatch on %v", p, sensor)
+			}
+			normalisers[sensor] = norm
+		}
+		for name, field := range base.schema {
+			if other, ok := schema[name]; ok && !sameField(other, field) {
+				return nil, fmt.Errorf("net %v: schema mismatch on %v", p, name)
+			}
+			schema[name] = field
+		}
+	}
+
+	lay, err := newLayout(parts, sensors, actions)
+	if err != nil {
+		return nil, err
+	}
+	neurons := make([]Layer, depth)
+	frozen := make([][]bool, depth)
+	for i := 0; i < depth-1; i++ {
+		for p, base := range bases {
+			inputs := lay.inputs(p, i)
+			if i < len(base.neurons) {
+				for j, neu := range base.neurons[i] {
+					neurons[i] = append(neurons[i], spread(neu, inputs, lay.width(i-1, len(sensors))))
+					frozen[i] = append(frozen[i], base.IsFrozen(i, j))
+				}
+				continue
+			}
+			for k := range parts[p].actions {
+				neurons[i] = append(neurons[i], passNeuron(inputs[k], lay.width(i-1, len(sensors))))
+				frozen[i] = append(frozen[i], false)
+			}
+		}
+	}
+	neurons[depth-1] = make(Layer, len(actions))
+	frozen[depth-1] = make([]bool, len(actions))
+	for p, base := range bases {
+		inputs := lay.inputs(p, depth-1)
+		for k, position := range lay.actions[p] {
+			if len(base.neurons) == depth {
+				neurons[depth-1][position] = spread(base.neurons[depth-1][k], inputs, lay.width(depth-2, len(sensors)))
+				frozen[depth-1][position] = base.IsFrozen(depth-1, k)
+			} else {
+				neurons[depth-1][position] = passNeuron(inputs[k], lay.width(depth-2, len(sensors)))
+			}
+		}
+	}
+
+	options := []Option{
+		WithActivations(activations...),
+		WithNormalisers(normalisers),
+		WithSchema(schema),
+		withParts(parts),
+		withFrozen(frozen),
+	}
+	if customBounds {
+		options = append(options, WithBounds(bounds...))
+	}
+	return NewNeuralNet(sensors, actions, neurons, options...)
+}
+
+// Split return the nets a merged net was made of
+func Split(net NeuralNet) ([]NeuralNet, error) {
+	base, err := asNeuralNet(net)
+	if err != nil {
+		return nil, err
+	}
+	if base.parts == nil {
+		return nil, fmt.Errorf("not a merged net")
+	}
+	lay, err := newLayout(base.parts, base.sensors, base.actions)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]NeuralNet, len(base.parts))
+	for p, current := range base.parts {
+		depth := len(current.widths)
+		neurons := make([]Layer, depth)
+		frozen := make([][]bool, depth)
+		for i := 0; i < depth; i++ {
+			inputs := lay.inputs(p, i)
+			var positions []int
+			if i == lay.depth-1 {
+				positions = lay.actions[p]
+			} else {
+				for k := 0; k < lay.blocks[i][p]; k++ {
+					positions = append(positions, lay.offsets[i][p]+k)
+				}
+			}
+			frozen[i] = make([]bool, len(positions))
+			for k, position := range positions {
+				neurons[i] = append(neurons[i], gather(base.neurons[i][position], inputs))
+				frozen[i][k] = base.IsFrozen(i, position)
+			}
+		}
+
+		names := toSet(append(current.sensors, current.actions...))
+		activations := make([]Activation, depth)
+		bounds := make([]Bounds, depth)
+		for i := range activations {
+			activations[i] = base.GetActivation(i)
+			bounds[i] = base.GetBounds(i)
+		}
+		normalisers := make(map[string]Normaliser)
+		for sensor, norm := range base.normalisers {
+			if names[sensor] {
+				normalisers[sensor] = norm
+			}
+		}
+		schema := make(Schema)
+		for name, field := range base.schema {
+			if names[name] {
+				schema[name] = field
+			}
+		}
+
+		options := []Option{
+			WithActivations(activations...),
+			WithNormalisers(normalisers),
+			WithSchema(schema),
+			withFrozen(frozen),
+		}
+		if base.bounds != nil {
+			options = append(options, WithBounds(bounds...))
+		}
+		if res[p], err = NewNeuralNet(current.sensors, current.actions, neurons, options...); err != nil {
+			return nil, fmt.Errorf("part %v: %v", p, err)
+		}
+	}
+	return res, nil
+}
+
+func withParts(parts []part) Option {
+	return func(net *neuralnet) error {
+		lay, err := newLayout(parts, net.sensors, net.actions)
+		if err != nil {
+			return err
+		}
+		if lay.depth != len(net.neurons) {
+			return fmt.Errorf("parts expect %v layers, got %v", lay.depth, len(net.neurons))
+		}
+		for i := 0; i < lay.depth-1; i++ {
+			if width := lay.width(i, 0); width != len(net.neurons[i]) {
+				return fmt.Errorf("parts expect %v neurons on layer %v, got %v", width, i, len(net.neurons[i]))
+			}
+		}
+		net.parts = parts
+		return nil
+	}
+}
+
+func newLayout(parts []part, sensors, actions []string) (layout, error) {
+	var lay layout
+	sensorIndex := make(map[string]int)
+	for i, sensor := range sensors {
+		sensorIndex[sensor] = i
+	}
+	actionIndex := make(map[string]int)
+	for i, action := range actions {
+		actionIndex[action] = i
+	}
+
+	owned := 0
+	for p, current := range parts {
+		if len(current.widths) == 0 || current.widths[len(current.widths)-1] != len(current.actions) {
+			return lay, fmt.Errorf("part %v: inconsistent widths", p)
+		}
+		if len(current.widths) > lay.depth {
+			lay.depth = len(current.widths)
+		}
+		var positions []int
+		for _, sensor := range current.sensors {
+			position, ok := sensorIndex[sensor]
+			if !ok {
+				return lay, fmt.Errorf("part %v: unknown sensor %v", p, sensor)
+			}
+			positions = append(positions, position)
+		}
+		lay.sensors = append(lay.sensors, positions)
+		positions = nil
+		for _, action := range current.actions {
+			position, ok := actionIndex[action]
+			if !ok {
+				return lay, fmt.Errorf("part %v: unknown action %v", p, action)
+			}
+			positions = append(positions, position)
+		}
+		lay.actions = append(lay.actions, positions)
+		owned += len(positions)
+	}
+	if owned != len(actions) {
+		return lay, fmt.Errorf("parts own %v actions, expected %v", owned, len(actions))
+	}
+
+	for i := 0; i < lay.depth-1; i++ {
+		offset := 0
+		offsets := make([]int, len(parts))
+		blocks := make([]int, len(parts))
+		for p, current := range parts {
+			offsets[p] = offset
+			if i < len(current.widths) {
+				blocks[p] = current.widths[i]
+			} else {
+				blocks[p] = len(current.actions)
+			}
+			offset += blocks[p]
+		}
+		lay.offsets = append(lay.offsets, offsets)
+		lay.blocks = append(lay.blocks, blocks)
+	}
+	return lay, nil
+}
+
+// width return the neurons on a layer but the last, or sensors when negative
+func (lay layout) width(layer, sensors int) int {
+	if layer < 0 {
+		return sensors
+	}
+	res := 0
+	for _, block := range lay.blocks[layer] {
+		res += block
+	}
+	return res
+}
+
+// inputs return the positions a part’s neurons read on the given layer
+func (lay layout) inputs(part, layer int) []int {
+	if layer == 0 {
+		return lay.sensors[part]
+	}
+	res := make([]int, lay.blocks[layer-1][part])
+	for k := range res {
+		res[k] = lay.offsets[layer-1][part] + k
+	}
+	return res
+}
+
+// mutable tells which neurons of a merged net evolve: pass-through ones don’t
+func (lay layout) mutable(parts []part, layer, index int) bool {
+	if layer == lay.depth-1 {
+		for p, positions := range lay.actions {
+			for _, position := range positions {
+				if position == index {
+					return len(parts[p].widths) == lay.depth
+				}
+			}
+		}
+		return false
+	}
+	for p, offset := range lay.offsets[layer] {
+		if index >= offset && index < offset+lay.blocks[layer][p] {
+			return layer < len(parts[p].widths)
+		}
+	}
+	return false
+}
+
+// owner return the part a neuron of a merged net belongs to
+func (lay layout) owner(layer, index int) int {
+	if layer == lay.depth-1 {
+		for p, positions := range lay.actions {
+			for _, position := range positions {
+				if position == index {
+					return p
+				}
+			}
+		}
+	}
+	for p, offset := range lay.offsets[layer] {
+		if index >= offset && index < offset+lay.blocks[layer][p] {
+			return p
+		}
+	}
+	return -1
+}
+
+func (net neuralnet) mergedChild(dev int) []Layer {
+	lay, _ := newLayout(net.parts, net.sensors, net.actions)
+	neurons := make([]Layer, len(net.neurons))
+	for i, layer := range net.neurons {
+		neurons[i] = make(Layer, len(layer))
+		for j, neu := range layer {
+			if !lay.mutable(net.parts, i, j) || net.IsFrozen(i, j) {
+				neurons[i][j] = neu
+				continue
+			}
+			inputs := lay.inputs(lay.owner(i, j), i)
+			child := gather(neu, inputs).Child(dev)
+			if net.bounds != nil {
+				child = boundNeuron(child, net.bounds[i])
+			}
+			neurons[i][j] = overlay(neu, child, inputs)
+		}
+	}
+	return neurons
+}
+
+func passesThrough(output, through Activation) bool {
+	if output == Sigmoid {
+		return false
+	}
+	switch through {
+	case Linear:
+		return true
+	case ReLU:
+		return output == ReLU || output == Step
+	case Step:
+		return output == Step
+	}
+	return false
+}
+
+// spread lay the genes of a neuron out onto the given positions
+func spread(neu Neuron, positions []int, size int) Neuron {
+	res := make(neuron, size)
+	for k, position := range positions {
+		res[position] = neu.GetGene(k)
+	}
+	return res
+}
+
+// gather collect the genes found on the given positions
+func gather(neu Neuron, positions []int) Neuron {
+	res := make(neuron, len(positions))
+	for k, position := range positions {
+		res[k] = neu.GetGene(position)
+	}
+	return res
+}
+
+// overlay replace the genes on the given positions
+func overlay(neu, genes Neuron, positions []int) Neuron {
+	res := make(neuron, neu.GetSize())
+	for k := range res {
+		res[k] = neu.GetGene(k)
+	}
+	for k, position := range positions {
+		res[position] = genes.GetGene(k)
+	}
+	return res
+}
+
+func passNeuron(position, size int) Neuron {
+	res := make(neuron, size)
+	res[position] = 1
+	return res
+}
+
+func sameField(a, b Field) bool {
+	if (a.Default == nil) != (b.Default == nil) {
+		return false
+	}
+	if a.Default != nil && *a.Default != *b.Default {
+		return false
+	}
+	a.Default, b.Default = nil, nil
+	return a == b
+}
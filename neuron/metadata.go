@@ -0,0 +1,79 @@
+package neuron
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Metadata records where a net comes from, as free-form keys and values
+type Metadata map[string]string
+
+// Well-known metadata keys
+const (
+	MetaID         = "id"
+	MetaGeneration = "generation" // decimal integer
+	MetaFitness    = "fitness"
+	MetaParents    = "parents" // comma-separated IDs
+	MetaRun        = "run"
+	MetaCreated    = "created" // RFC 3339 time
+	MetaTags       = "tags"    // comma-separated
+)
+
+// WithMetadata attach metadata to the net
+func WithMetadata(metadata Metadata) Option {
+	return func(net *neuralnet) error {
+		net.metadata = make(Metadata)
+		for key, value := range metadata {
+			if key == "" {
+				return fmt.Errorf("empty metadata key")
+			}
+			net.metadata[key] = value
+		}
+		return nil
+	}
+}
+
+func (net neuralnet) GetMetadata() Metadata {
+	res := make(Metadata)
+	for key, value := range net.metadata {
+		res[key] = value
+	}
+	return res
+}
+
+// childMetadata derive the metadata of a child: the ID of the net becomes
+// its parents, an integer generation is incremented, the ID, fitness and
+// creation time are dropped, and every other key is copied
+func (net neuralnet) childMetadata() Metadata {
+	if net.metadata == nil {
+		return nil
+	}
+	res := make(Metadata)
+	for key, value := range net.metadata {
+		switch key {
+		case MetaID, MetaFitness, MetaCreated, MetaParents:
+		case MetaGeneration:
+			if generation, err := strconv.Atoi(value); err == nil {
+				value = strconv.Itoa(generation + 1)
+			}
+			res[key] = value
+		default:
+			res[key] = value
+		}
+	}
+	if id, ok := net.metadata[MetaID]; ok {
+		res[MetaParents] = id
+	}
+	return res
+}
+
+// metadataKeys return the metadata keys in order
+func (net neuralnet) metadataKeys() []string {
+	res := make([]string, 0, len(net.metadata))
+	for key := range net.metadata {
+		res = append(res, key)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -18,19 +19,41 @@ type NeuralNet interface {
 	GetChild(int) NeuralNet
 	GetSensors() []string
 	GetNeurons(int) []Neuron
+	GetActivation(int) Activation
+	GetBounds(int) Bounds
+	GetNormaliser(string) (Normaliser, bool)
+	GetSchema() Schema
+	GetMetadata() Metadata
+	IsFrozen(int, int) bool
 	Compute(map[string]float64) (map[string]bool, error)
+	Activate(map[string]float64) (map[string]float64, error)
 	Save(io.Writer) error
+	SaveFormat(io.Writer, Format) error
+	SaveCompressed(io.Writer, int) error
+	MarshalBinary() ([]byte, error)
+	MarshalJSON() ([]byte, error)
+	WriteTo(io.Writer) (int64, error)
 	String() string
 }
 
+// Option configures optional features of a neural net
+type Option func(*neuralnet) error
+
 type neuralnet struct {
-	actions []string
-	neurons []Layer
-	sensors []string
+	actions     []string
+	neurons     []Layer
+	sensors     []string
+	activations []Activation
+	bounds      []Bounds
+	normalisers map[string]Normaliser
+	schema      Schema
+	parts       []part
+	frozen      [][]bool
+	metadata    Metadata
 }
 
 // NewNeuralNet instantiate a new neural net
-func NewNeuralNet(sensors, actions []string, neurons []Layer) (NeuralNet, error) {
+func NewNeuralNet(sensors, actions []string, neurons []Layer, options ...Option) (NeuralNet, error) {
 	if len(neurons) == 0 {
 		return nil, fmt.Errorf("no neuron supplied")
 	}
@@ -64,16 +87,98 @@ func NewNeuralNet(sensors, actions []string, neurons []Layer) (NeuralNet, error)
 		return nil, fmt.Errorf("expected one last neuron [%v] for each action [%v]", len(last), actionsCount)
 	}
 
-	return &neuralnet{sortedActions, neurons, sortedSensors}, nil
+	net := &neuralnet{
+		actions: sortedActions,
+		neurons: neurons,
+		sensors: sortedSensors,
+	}
+	for _, option := range options {
+		if err := option(net); err != nil {
+			return nil, err
+		}
+	}
+	return net, nil
+}
+
+// Configure return a copy of the neural net with further options applied
+func Configure(net NeuralNet, options ...Option) (NeuralNet, error) {
+	base, err := asNeuralNet(net)
+	if err != nil {
+		return nil, err
+	}
+	res := *base
+	for _, option := range options {
+		if err := option(&res); err != nil {
+			return nil, err
+		}
+	}
+	return &res, nil
 }
 
-// LoadNet load a new neural net from an I/O reader
+// WithActivations set the activation of each layer
+func WithActivations(activations ...Activation) Option {
+	return func(net *neuralnet) error {
+		if len(activations) != len(net.neurons) {
+			return fmt.Errorf("expected %v activations, got %v", len(net.neurons), len(activations))
+		}
+		for i, activation := range activations {
+			if !activation.valid() {
+				return fmt.Errorf("layer %v: invalid activation %v", i, activation)
+			}
+		}
+		net.activations = make([]Activation, len(activations))
+		copy(net.activations, activations)
+		return nil
+	}
+}
+
+// NetValue holds a neural net decoded through encoding.BinaryUnmarshaler
+type NetValue struct {
+	NeuralNet
+}
+
+// UnmarshalBinary decode a saved net, in any supported format, into the value
+func (value *NetValue) UnmarshalBinary(data []byte) error {
+	input := bytes.NewReader(data)
+	net, err := LoadNet(input)
+	if err == io.EOF {
+		return decodeError("header", err)
+	} else if err != nil {
+		return err
+	}
+	if input.Len() > 0 {
+		return malformed("%v trailing bytes after net", input.Len())
+	}
+	value.NeuralNet = net
+	return nil
+}
+
+// LoadNet load a new neural net from an I/O reader, detecting gzip
+// compressed nets; an empty stream gives io.EOF, and undecodable data a
+// *DecodeError
 func LoadNet(input io.Reader) (NeuralNet, error) {
 	var buf [4]byte
 
-	// Discard long size
-	if _, err := input.Read(buf[:]); err != nil {
+	if _, err := io.ReadFull(input, buf[:]); err == io.EOF {
 		return nil, err
+	} else if err != nil {
+		return nil, decodeError("header", err)
+	}
+	if isGzip(buf) {
+		return loadCompressed(buf, input)
+	}
+	return loadNet(buf, input)
+}
+
+// loadNet read an uncompressed net whose first four bytes were already
+// consumed
+func loadNet(buf [4]byte, input io.Reader) (NeuralNet, error) {
+	if buf == magic {
+		return loadV2(input)
+	}
+	// Legacy files start with a uint16 size, which is discarded
+	if buf[2] != 0 || buf[3] != 0 {
+		return nil, ErrUnknownFormat
 	}
 
 	var err error
@@ -81,15 +186,15 @@ func LoadNet(input io.Reader) (NeuralNet, error) {
 	var actions []string
 	var neurons []Layer
 
-	if sensors, err = loadStrings(input); err != nil {
+	if sensors, err = loadStrings(input, "sensors"); err != nil {
 		return nil, err
 	}
-	if actions, err = loadStrings(input); err != nil {
+	if actions, err = loadStrings(input, "actions"); err != nil {
 		return nil, err
 	}
 
-	if _, err := input.Read(buf[:]); err != nil {
-		return nil, err
+	if _, err := io.ReadFull(input, buf[:]); err != nil {
+		return nil, decodeError("layer count", err)
 	}
 	size := int(binary.BigEndian.Uint16(buf[:]))
 	neurons = make([]Layer, size)
@@ -102,20 +207,42 @@ func LoadNet(input io.Reader) (NeuralNet, error) {
 		}
 	}
 
+	options, err := loadSections(input)
+	if err != nil {
+		return nil, err
+	}
+
 	// Put everything together
-	return NewNeuralNet(sensors, actions, neurons)
+	return buildNet(sensors, actions, neurons, options)
 }
 
 func (net neuralnet) GetChild(dev int) NeuralNet {
+	if net.parts != nil {
+		child := net
+		child.neurons = net.mergedChild(dev)
+		child.metadata = net.childMetadata()
+		return &child
+	}
+
 	neurons := make([]Layer, len(net.neurons))
 	for i, layer := range net.neurons {
 		current := make(Layer, len(layer))
 		for j, neuron := range layer {
+			if net.IsFrozen(i, j) {
+				current[j] = neuron
+				continue
+			}
 			current[j] = neuron.Child(dev)
+			if net.bounds != nil {
+				current[j] = boundNeuron(current[j], net.bounds[i])
+			}
 		}
 		neurons[i] = current
 	}
-	return &neuralnet{net.actions, neurons, net.sensors}
+	child := net
+	child.neurons = neurons
+	child.metadata = net.childMetadata()
+	return &child
 }
 
 func (net neuralnet) GetActions() []string {
@@ -139,58 +266,165 @@ func (net neuralnet) GetNeurons(index int) []Neuron {
 	return neurons
 }
 
-func (net neuralnet) Compute(incoming map[string]float64) (map[string]bool, error) {
-	if err := net.checkInput(incoming); err != nil {
-		return nil, err
+func (net neuralnet) GetActivation(index int) Activation {
+	if index >= len(net.activations) {
+		return ReLU
 	}
+	return net.activations[index]
+}
 
-	partial := make([]float64, len(incoming))
-	for i, sensor := range net.sensors {
-		partial[i] = incoming[sensor]
+func (net neuralnet) customActivations() bool {
+	for _, activation := range net.activations {
+		if activation != ReLU {
+			return true
+		}
 	}
+	return false
+}
 
-	for _, neurons := range net.neurons {
-		nextStep := make([]float64, len(neurons))
-		for i, neuron := range neurons {
-			nextStep[i] = float64(neuron.Compute(partial...))
-		}
-		partial = nextStep
+func (net neuralnet) Compute(incoming map[string]float64) (map[string]bool, error) {
+	outputs, err := net.activate(incoming)
+	if err != nil {
+		return nil, err
 	}
 
+	partial := outputs[len(outputs)-1]
+	activation := net.GetActivation(len(net.neurons) - 1)
 	res := make(map[string]bool)
 	for i, action := range net.actions {
-		res[action] = partial[i] > 0
+		res[action] = activation.fires(partial[i])
 	}
 
 	return res, nil
 }
 
-func (net neuralnet) checkInput(incoming map[string]float64) error {
-	if len(incoming) != len(net.sensors) {
-		return fmt.Errorf("incoming mismatch sensors")
+func (net neuralnet) Activate(incoming map[string]float64) (map[string]float64, error) {
+	outputs, err := net.activate(incoming)
+	if err != nil {
+		return nil, err
+	}
+
+	partial := outputs[len(outputs)-1]
+	res := make(map[string]float64)
+	for i, action := range net.actions {
+		res[action] = partial[i]
+	}
+	return res, nil
+}
+
+// activate return the outputs of every layer given the raw readings
+func (net neuralnet) activate(incoming map[string]float64) ([][]float64, error) {
+	partial, err := net.checkInput(incoming)
+	if err != nil {
+		return nil, err
 	}
+	for i, sensor := range net.sensors {
+		partial[i] = net.normalise(sensor, partial[i])
+	}
+	return net.forward(partial), nil
+}
+
+// forward return the outputs of every layer given the normalised readings
+func (net neuralnet) forward(partial []float64) [][]float64 {
+	outputs := make([][]float64, len(net.neurons))
+	for index, neurons := range net.neurons {
+		activation := net.GetActivation(index)
+		nextStep := make([]float64, len(neurons))
+		for i, neuron := range neurons {
+			nextStep[i] = activation.apply(weightedSum(neuron, partial))
+		}
+		outputs[index] = nextStep
+		partial = nextStep
+	}
+	return outputs
+}
+
+func (net neuralnet) checkInput(incoming map[string]float64) ([]float64, error) {
 	sensors := make(map[string]bool)
 	for _, sensor := range net.sensors {
 		sensors[sensor] = true
 	}
 	for key := range incoming {
 		if !sensors[key] {
-			return fmt.Errorf("incoming mismatch sensors")
+			return nil, fmt.Errorf("incoming mismatch sensors")
 		}
 	}
-	return nil
+
+	res := make([]float64, len(net.sensors))
+	for i, sensor := range net.sensors {
+		field := net.schema[sensor]
+		value, ok := incoming[sensor]
+		if !ok {
+			if field.Default == nil {
+				return nil, fmt.Errorf("incoming mismatch sensors")
+			}
+			value = *field.Default
+		}
+		if field.HasRange() && (value < field.Min || value > field.Max) {
+			return nil, fmt.Errorf("sensor %v: value %v out of range [%v, %v]", sensor, value, field.Min, field.Max)
+		}
+		res[i] = value
+	}
+	return res, nil
 }
 
 func (net neuralnet) String() string {
 	var buf strings.Builder
 	buf.WriteString("SENSORS: ")
-	buf.WriteString(strings.Join(net.sensors, ", "))
+	buf.WriteString(joinNames(net.sensors))
 	buf.WriteString("\nACTIONS: ")
-	buf.WriteString(strings.Join(net.actions, ", "))
+	buf.WriteString(joinNames(net.actions))
+	if net.customActivations() {
+		buf.WriteString("\nACTIVATIONS: ")
+		for i, activation := range net.activations {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(activation.String())
+		}
+	}
+	for i, bounds := range net.bounds {
+		fmt.Fprintf(&buf, "\nBOUNDS %v: %v", i, bounds)
+	}
+	for _, name := range net.documented() {
+		fmt.Fprintf(&buf, "\nFIELD %v: %v", quoteName(name), net.schema[name])
+	}
+	for i, current := range net.parts {
+		widths := make([]string, len(current.widths))
+		for j, width := range current.widths {
+			widths[j] = fmt.Sprint(width)
+		}
+		fmt.Fprintf(&buf, "\nPART %v: %v -> %v; widths %v", i, joinNames(current.sensors), joinNames(current.actions), strings.Join(widths, ", "))
+	}
+	for i, flags := range net.frozen {
+		var frozen []string
+		for j, flag := range flags {
+			if flag {
+				frozen = append(frozen, fmt.Sprint(j))
+			}
+		}
+		if frozen != nil {
+			fmt.Fprintf(&buf, "\nFROZEN %v: %v", i, strings.Join(frozen, ", "))
+		}
+	}
+	for _, sensor := range net.normalised() {
+		fmt.Fprintf(&buf, "\nNORMALISE %v: %v", quoteName(sensor), net.normalisers[sensor])
+	}
+	for _, key := range net.metadataKeys() {
+		fmt.Fprintf(&buf, "\nMETA %v: %q", quoteName(key), net.metadata[key])
+	}
 	buf.WriteString("\nNEURONS:\n")
+	var line []byte
 	for _, neurons := range net.neurons {
 		for _, neuron := range neurons {
-			buf.WriteString(neuron.String())
+			line = line[:0]
+			for i := 0; i < neuron.GetSize(); i++ {
+				if i > 0 {
+					line = append(line, ' ')
+				}
+				line = strconv.AppendInt(line, int64(neuron.GetGene(i)), 10)
+			}
+			buf.Write(line)
 			buf.WriteByte(0x0a)
 		}
 		buf.WriteByte(0x0a)
@@ -200,11 +434,51 @@ func (net neuralnet) String() string {
 }
 
 func (net neuralnet) Save(out io.Writer) error {
+	return net.SaveFormat(out, CurrentFormat)
+}
+
+func (net neuralnet) MarshalBinary() ([]byte, error) {
+	if err := net.checkBounds(); err != nil {
+		return nil, err
+	}
+	return net.encodeV2()
+}
+
+func (net neuralnet) WriteTo(out io.Writer) (int64, error) {
+	data, err := net.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	written, err := out.Write(data)
+	return int64(written), err
+}
+
+func (net neuralnet) SaveFormat(out io.Writer, format Format) error {
+	if err := net.checkBounds(); err != nil {
+		return err
+	}
+	switch format {
+	case Legacy:
+		return net.saveLegacy(out)
+	case V2:
+		return net.saveV2(out)
+	default:
+		return fmt.Errorf("unsupported format version %v", format)
+	}
+}
+
+func (net neuralnet) saveLegacy(out io.Writer) error {
+	if err := checkGenes(net.neurons); err != nil {
+		return err
+	}
 	var buf bytes.Buffer
 	var current [4]byte
+	var scratch []byte
 
 	// Serialise sensors
-	binary.BigEndian.PutUint16(current[:], uint16(len(net.sensors)))
+	if err := putLegacyCount(current[:], "sensors", len(net.sensors)); err != nil {
+		return err
+	}
 	buf.Write(current[:])
 	for _, sensor := range net.sensors {
 		buf.Write([]byte(sensor))
@@ -212,7 +486,9 @@ func (net neuralnet) Save(out io.Writer) error {
 	}
 
 	// Serialise actions
-	binary.BigEndian.PutUint16(current[:], uint16(len(net.actions)))
+	if err := putLegacyCount(current[:], "actions", len(net.actions)); err != nil {
+		return err
+	}
 	buf.Write(current[:])
 	for _, action := range net.actions {
 		buf.Write([]byte(action))
@@ -220,26 +496,28 @@ func (net neuralnet) Save(out io.Writer) error {
 	}
 
 	// Serialise neurons
-	binary.BigEndian.PutUint16(current[:], uint16(len(net.neurons)))
+	if err := putLegacyCount(current[:], "layers", len(net.neurons)); err != nil {
+		return err
+	}
 	buf.Write(current[:])
 	for _, neurons := range net.neurons {
-		binary.BigEndian.PutUint16(current[:], uint16(len(neurons)))
+		if err := putLegacyCount(current[:], "neurons", len(neurons)); err != nil {
+			return err
+		}
 		buf.Write(current[:])
 		for _, neuron := range neurons {
-			ch := neuron.Marshal()
-			value, ok := <-ch
-			for ok {
-				buf.WriteByte(value)
-				value, ok = <-ch
-			}
+			scratch = appendLegacyNeuron(scratch[:0], neuron)
+			buf.Write(scratch)
 		}
 	}
 
-	// Add tail
-	buf.Write([]byte{0, 0, 0, 0})
+	// Add optional sections and tail
+	net.saveSections(&buf)
 
 	// Add header
-	binary.BigEndian.PutUint16(current[:], uint16(buf.Len()))
+	if err := putLegacyCount(current[:], "length", buf.Len()); err != nil {
+		return err
+	}
 	if _, err := out.Write(current[:]); err != nil {
 		return err
 	}
@@ -266,15 +544,15 @@ func usort(args []string) []string {
 
 func loadNeurons(input io.Reader) ([]Neuron, error) {
 	var buf [4]byte
-	if _, err := input.Read(buf[:]); err != nil {
-		return nil, err
+	if _, err := io.ReadFull(input, buf[:]); err != nil {
+		return nil, decodeError("neuron count", err)
 	}
 	size := int(binary.BigEndian.Uint16(buf[:]))
 	res := make([]Neuron, size)
 
 	for i := 0; i < size; i++ {
 		var err error
-		res[i], err = NewNeuron(input)
+		res[i], err = readFile(input, false)
 		if err != nil {
 			return nil, err
 		}
@@ -282,24 +560,26 @@ func loadNeurons(input io.Reader) ([]Neuron, error) {
 	return res, nil
 }
 
-func loadStrings(input io.Reader) ([]string, error) {
+func loadStrings(input io.Reader, what string) ([]string, error) {
 	var buf [4]byte
-	if _, err := input.Read(buf[:]); err != nil {
-		return nil, err
+	if _, err := io.ReadFull(input, buf[:]); err != nil {
+		return nil, decodeError(what, err)
 	}
 	size := int(binary.BigEndian.Uint16(buf[:]))
 	set := make([]string, size)
 
+	// Read byte by byte, so nothing past the net is consumed
+	var str strings.Builder
 	for i := 0; i < size; i++ {
-		current := []byte{0xff}
-		var str strings.Builder
-		for current[0] != 0 {
-			if _, err := input.Read(current[:]); err != nil {
-				return nil, err
+		str.Reset()
+		for {
+			if _, err := io.ReadFull(input, buf[:1]); err != nil {
+				return nil, decodeError(what, err)
 			}
-			if current[0] != 0x00 {
-				str.WriteByte(current[0])
+			if buf[0] == 0x00 {
+				break
 			}
+			str.WriteByte(buf[0])
 		}
 		set[i] = str.String()
 	}
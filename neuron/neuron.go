@@ -6,8 +6,10 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"math"
 	"math/rand"
 	"strings"
+	"sync"
 )
 
 // Neuron represents a neuron
@@ -23,6 +25,16 @@ type Neuron interface {
 
 type neuron []int
 
+// Marshalled neurons start with a uint16 size; from largeNeuron genes on, the
+// uint16 holds largeNeuron and a uint32 size follows. Legacy nets, whose
+// counts fit a uint16, keep the plain size.
+const largeNeuron = 0xffff
+
+const (
+	geneChunk = 1024                // genes decoded at once from streams
+	maxGenes  = math.MaxInt32/4 - 2 // keeps byte sizes within int
+)
+
 // NewNeuron create a new neuron
 func NewNeuron(data interface{}) (Neuron, error) {
 
@@ -39,17 +51,16 @@ func NewNeuron(data interface{}) (Neuron, error) {
 		return neuronFromBytes(value)
 
 	case io.Reader:
-		return readFile(value)
+		return readFile(value, true)
 
 	case *bytes.Buffer:
 		return neuronFromBytes(value.Bytes())
 
 	case int:
-		neu := make(neuron, value)
-		for i := 0; i < value; i++ {
-			neu[i] = int(rand.Int31n(2000)) - 1000
-		}
-		return neu, nil
+		return newRandomNeuron(NeuronSpec{Size: value})
+
+	case NeuronSpec:
+		return newRandomNeuron(value)
 
 	case string:
 		decoder := base32.HexEncoding.WithPadding(base32.NoPadding)
@@ -104,103 +115,230 @@ func (neu neuron) Compute(data ...float64) int {
 func (neu neuron) Child(dev int) Neuron {
 	child := make(neuron, neu.GetSize())
 	for i, value := range neu {
-		child[i] = value + int(rand.Int31n(int32(dev))) - (dev / 2)
+		child[i] = saturatingAdd(value, int(rand.Int31n(int32(dev)))-(dev/2))
 	}
 	return child
 }
 
+// Marshal stream the marshalled neuron, panicking with a *GeneRangeError
+// for genes the format cannot hold; prefer MarshalBinary or WriteTo
 func (neu neuron) Marshal() <-chan byte {
-	ch := make(chan byte)
-
-	go func() {
-		defer close(ch)
-
-		size := neu.GetSize()
-		var buf [4]byte
-		binary.BigEndian.PutUint16(buf[:], uint16(size))
-		ch <- buf[0]
-		ch <- buf[1]
-
-		for _, gene := range neu {
-			var cur uint32
-			if gene < 0 {
-				cur = uint32(int64(0x100000000) + int64(gene))
-			} else {
-				cur = uint32(gene)
-			}
-			binary.BigEndian.PutUint32(buf[:], cur)
-			for i := 0; i < 4; i++ {
-				ch <- buf[i]
-			}
-		}
-	}()
-
+	data, err := neu.MarshalBinary()
+	if err != nil {
+		panic(err)
+	}
+	ch := make(chan byte, len(data))
+	for _, value := range data {
+		ch <- value
+	}
+	close(ch)
 	return ch
 }
 
+func (neu neuron) MarshalBinary() ([]byte, error) {
+	if err := neu.checkGenes(); err != nil {
+		return nil, err
+	}
+	return neu.appendTo(make([]byte, 0, neu.binarySize())), nil
+}
+
+func (neu neuron) WriteTo(out io.Writer) (int64, error) {
+	if err := neu.checkGenes(); err != nil {
+		return 0, err
+	}
+	buf := bufferPool.Get().(*[]byte)
+	defer bufferPool.Put(buf)
+	*buf = neu.appendTo((*buf)[:0])
+	written, err := out.Write(*buf)
+	return int64(written), err
+}
+
+func (neu *neuron) UnmarshalBinary(data []byte) error {
+	res, err := unmarshalNeuron(data)
+	if err != nil {
+		return err
+	}
+	*neu = res
+	return nil
+}
+
+// checkGenes report the first gene the format cannot hold
+func (neu neuron) checkGenes() error {
+	if k := outOfRange(neu); k >= 0 {
+		return &GeneRangeError{Layer: -1, Neuron: -1, Gene: k, Value: neu[k], Bounds: DefaultBounds}
+	}
+	return nil
+}
+
+func (neu neuron) binarySize() int {
+	if len(neu) < largeNeuron {
+		return 2 + 4*len(neu)
+	}
+	return 6 + 4*len(neu)
+}
+
+// String return the marshalled neuron in base32, panicking like Marshal
 func (neu neuron) String() string {
-	var buf bytes.Buffer
-	ch := neu.Marshal()
-	value, ok := <-ch
-	for ok {
-		buf.WriteByte(value)
-		value, ok = <-ch
+	data, err := neu.MarshalBinary()
+	if err != nil {
+		panic(err)
 	}
 	encoder := base32.HexEncoding.WithPadding(base32.NoPadding)
-	return encoder.EncodeToString(buf.Bytes())
+	return encoder.EncodeToString(data)
 }
 
-func readFile(input io.Reader) (Neuron, error) {
-	var buf [2]byte
-	if _, err := input.Read(buf[:]); err != nil {
-		return nil, err
+// NeuronValue holds a neuron decoded through encoding.BinaryUnmarshaler
+type NeuronValue struct {
+	Neuron
+}
+
+// UnmarshalBinary decode a marshalled neuron into the value
+func (value *NeuronValue) UnmarshalBinary(data []byte) error {
+	neu, err := unmarshalNeuron(data)
+	if err != nil {
+		return err
 	}
-	size := 4 * int(binary.BigEndian.Uint16(buf[:]))
-	data := make([]byte, 2+size)
-	copy(data, buf[:])
-	if _, err := input.Read(data[2:]); err != nil {
-		return nil, err
+	value.Neuron = neu
+	return nil
+}
+
+// bufferPool recycles marshalling buffers across WriteTo calls
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new([]byte) },
+}
+
+// appendLegacyNeuron append the neuron to buf as legacy nets hold it, with
+// a plain uint16 size
+func appendLegacyNeuron(buf []byte, neu Neuron) []byte {
+	value, ok := neu.(neuron)
+	if !ok {
+		value = make(neuron, neu.GetSize())
+		for i := range value {
+			value[i] = neu.GetGene(i)
+		}
 	}
-	return neuronFromBytes(data)
+	buf = append(buf, byte(len(value)>>8), byte(len(value)))
+	return value.appendGenes(buf)
 }
 
-func neuronFromBytes(input []byte) (Neuron, error) {
-	res := make(chan int)
-	defer close(res)
-	ech := make(chan error)
-	defer close(ech)
+// appendTo append a uint16 size, escaped to a uint32 for large neurons, and
+// the genes as int32
+func (neu neuron) appendTo(buf []byte) []byte {
+	var current [4]byte
+	if len(neu) < largeNeuron {
+		binary.BigEndian.PutUint16(current[:], uint16(len(neu)))
+		buf = append(buf, current[:2]...)
+	} else {
+		binary.BigEndian.PutUint32(current[:], uint32(len(neu)))
+		buf = append(buf, 0xff, 0xff)
+		buf = append(buf, current[:]...)
+	}
+	return neu.appendGenes(buf)
+}
 
-	size := int(binary.BigEndian.Uint16(input))
-	go processBytes(input[2:], size, res, ech)
-	neu := make(neuron, size)
+func (neu neuron) appendGenes(buf []byte) []byte {
+	var current [4]byte
+	for _, gene := range neu {
+		binary.BigEndian.PutUint32(current[:], uint32(int32(gene)))
+		buf = append(buf, current[:]...)
+	}
+	return buf
+}
+
+// unmarshalNeuron decode exactly one marshalled neuron
+func unmarshalNeuron(data []byte) (neuron, error) {
+	size, head, err := neuronSize(data)
+	if err != nil {
+		return nil, err
+	}
+	if (len(data)-head)/4 < size {
+		return nil, &DecodeError{What: "neuron genes", Err: ErrTruncated}
+	}
+	if len(data) != head+4*size {
+		return nil, malformed("%v trailing bytes after neuron", len(data)-head-4*size)
+	}
+	return appendGenes(make(neuron, 0, size), data[head:]), nil
+}
 
-	for i := 0; i < size; {
-		select {
-		case err := <-ech:
+// readFile read a marshalled neuron; escaped tells whether its size may be
+// escaped, which legacy nets never are
+func readFile(input io.Reader, escaped bool) (Neuron, error) {
+	var buf [4 * geneChunk]byte
+	if _, err := io.ReadFull(input, buf[:2]); err != nil {
+		return nil, decodeError("neuron size", err)
+	}
+	size := int(binary.BigEndian.Uint16(buf[:2]))
+
+	if size == largeNeuron && escaped {
+		if _, err := io.ReadFull(input, buf[:4]); err != nil {
+			return nil, decodeError("neuron size", err)
+		}
+		var err error
+		if size, err = escapedSize(binary.BigEndian.Uint32(buf[:4])); err != nil {
 			return nil, err
-		case value := <-res:
-			neu[i] = value
-			i++
-		default:
 		}
 	}
 
+	// Grow while reading, so a bogus size cannot claim much memory
+	neu := make(neuron, 0, minInt(size, geneChunk))
+	for len(neu) < size {
+		count := minInt(size-len(neu), geneChunk)
+		if _, err := io.ReadFull(input, buf[:4*count]); err != nil {
+			return nil, decodeError("neuron genes", err)
+		}
+		neu = appendGenes(neu, buf[:4*count])
+	}
 	return neu, nil
 }
 
-func processBytes(body []byte, size int, res chan<- int, ech chan<- error) {
-	defer func() {
-		if err, ok := recover().(error); ok {
-			ech <- err
-		}
-	}()
-
-	for i := 0; i < size; i++ {
-		value := binary.BigEndian.Uint32(body[i*4:])
-		if value >= 0x80000000 {
-			res <- int(int64(value) - int64(0x100000000))
-		} else {
-			res <- int(value)
-		}
+func neuronFromBytes(input []byte) (Neuron, error) {
+	size, head, err := neuronSize(input)
+	if err != nil {
+		return nil, err
+	}
+	body := input[head:]
+	if len(body)/4 < size {
+		return nil, &DecodeError{What: "neuron genes", Err: ErrTruncated}
+	}
+	return appendGenes(make(neuron, 0, size), body[:4*size]), nil
+}
+
+// neuronSize parse the size of a marshalled neuron, returning it with the
+// length of its header
+func neuronSize(input []byte) (int, int, error) {
+	if len(input) < 2 {
+		return 0, 0, &DecodeError{What: "neuron size", Err: ErrTruncated}
+	}
+	size := int(binary.BigEndian.Uint16(input))
+	if size != largeNeuron {
+		return size, 2, nil
+	}
+	if len(input) < 6 {
+		return 0, 0, &DecodeError{What: "neuron size", Err: ErrTruncated}
+	}
+	size, err := escapedSize(binary.BigEndian.Uint32(input[2:]))
+	return size, 6, err
+}
+
+// escapedSize check the uint32 size following a largeNeuron escape, which
+// smaller neurons never use
+func escapedSize(value uint32) (int, error) {
+	if value < largeNeuron || uint64(value) > maxGenes {
+		return 0, malformed("escaped neuron size %v", value)
+	}
+	return int(value), nil
+}
+
+func appendGenes(neu neuron, data []byte) neuron {
+	for i := 0; i+4 <= len(data); i += 4 {
+		neu = append(neu, int(int32(binary.BigEndian.Uint32(data[i:]))))
+	}
+	return neu
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
 	}
+	return b
 }
@@ -0,0 +1,180 @@
+package neuron
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// NormMethod tells how a normaliser rescales its sensor
+type NormMethod uint8
+
+const (
+	// MinMax maps [Min, Max] onto [0, 1]
+	MinMax NormMethod = iota
+	// Standard maps Mean onto 0 and one Std onto 1
+	Standard
+)
+
+var normMethodNames = []string{"minmax", "standard"}
+
+func (method NormMethod) String() string {
+	if int(method) < len(normMethodNames) {
+		return normMethodNames[method]
+	}
+	return fmt.Sprintf("method(%d)", uint8(method))
+}
+
+// MarshalText encode the method as its name
+func (method NormMethod) MarshalText() ([]byte, error) {
+	if int(method) >= len(normMethodNames) {
+		return nil, fmt.Errorf("invalid method %v", method)
+	}
+	return []byte(method.String()), nil
+}
+
+// UnmarshalText decode a method from its name
+func (method *NormMethod) UnmarshalText(text []byte) error {
+	for i, name := range normMethodNames {
+		if name == string(text) {
+			*method = NormMethod(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown normalisation method %q", text)
+}
+
+// Normaliser rescales one sensor before it reaches the first layer
+type Normaliser struct {
+	Method NormMethod `json:"method"`
+	Min    float64    `json:"min,omitempty"`
+	Max    float64    `json:"max,omitempty"`
+	Mean   float64    `json:"mean,omitempty"`
+	Std    float64    `json:"std,omitempty"`
+	Clip   bool       `json:"clip,omitempty"` // clamp raw readings into [Min, Max] first
+}
+
+// Apply rescale a raw sensor reading
+func (norm Normaliser) Apply(value float64) float64 {
+	if norm.Clip {
+		value = math.Max(norm.Min, math.Min(norm.Max, value))
+	}
+	if norm.Method == Standard {
+		return (value - norm.Mean) / norm.Std
+	}
+	return (value - norm.Min) / (norm.Max - norm.Min)
+}
+
+func (norm Normaliser) String() string {
+	var buf strings.Builder
+	if norm.Method == Standard {
+		fmt.Fprintf(&buf, "standard %v %v", norm.Mean, norm.Std)
+	} else {
+		buf.WriteString("minmax")
+	}
+	if norm.Method == MinMax || norm.Clip {
+		fmt.Fprintf(&buf, " [%v, %v]", norm.Min, norm.Max)
+	}
+	if norm.Clip {
+		buf.WriteString(" clip")
+	}
+	return buf.String()
+}
+
+func (norm Normaliser) validate() error {
+	switch norm.Method {
+	case MinMax:
+		if !(norm.Max > norm.Min) {
+			return fmt.Errorf("empty range [%v, %v]", norm.Min, norm.Max)
+		}
+	case Standard:
+		if !(norm.Std > 0) {
+			return fmt.Errorf("invalid standard deviation %v", norm.Std)
+		}
+		if norm.Clip && !(norm.Max > norm.Min) {
+			return fmt.Errorf("invalid clipping range [%v, %v]", norm.Min, norm.Max)
+		}
+	default:
+		return fmt.Errorf("invalid method %v", norm.Method)
+	}
+	return nil
+}
+
+// FitNormalisers compute one normaliser per sensor from a dataset
+func FitNormalisers(method NormMethod, clip bool, samples []map[string]float64) (map[string]Normaliser, error) {
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no sample supplied")
+	}
+
+	res := make(map[string]Normaliser)
+	for sensor := range samples[0] {
+		norm := Normaliser{Method: method, Clip: clip, Min: math.Inf(1), Max: math.Inf(-1)}
+		sum, squares := 0.0, 0.0
+		for i, sample := range samples {
+			value, ok := sample[sensor]
+			if !ok {
+				return nil, fmt.Errorf("sample %v: missing sensor %v", i, sensor)
+			}
+			norm.Min = math.Min(norm.Min, value)
+			norm.Max = math.Max(norm.Max, value)
+			sum += value
+			squares += value * value
+		}
+		count := float64(len(samples))
+		norm.Mean = sum / count
+		norm.Std = math.Sqrt(math.Max(0, squares/count-norm.Mean*norm.Mean))
+
+		// Constant sensors would divide by zero
+		if norm.Max == norm.Min {
+			norm.Max = norm.Min + 1
+		}
+		if norm.Std == 0 {
+			norm.Std = 1
+		}
+		res[sensor] = norm
+	}
+	return res, nil
+}
+
+// WithNormalisers set the normalisers of some of the sensors
+func WithNormalisers(normalisers map[string]Normaliser) Option {
+	return func(net *neuralnet) error {
+		sensors := make(map[string]bool)
+		for _, sensor := range net.sensors {
+			sensors[sensor] = true
+		}
+		net.normalisers = make(map[string]Normaliser)
+		for sensor, norm := range normalisers {
+			if !sensors[sensor] {
+				return fmt.Errorf("normaliser for unknown sensor %v", sensor)
+			}
+			if err := norm.validate(); err != nil {
+				return fmt.Errorf("sensor %v: %v", sensor, err)
+			}
+			net.normalisers[sensor] = norm
+		}
+		return nil
+	}
+}
+
+func (net neuralnet) GetNormaliser(sensor string) (Normaliser, bool) {
+	norm, ok := net.normalisers[sensor]
+	return norm, ok
+}
+
+func (net neuralnet) normalise(sensor string, value float64) float64 {
+	if norm, ok := net.normalisers[sensor]; ok {
+		return norm.Apply(value)
+	}
+	return value
+}
+
+func (net neuralnet) normalised() []string {
+	res := make([]string, 0, len(net.normalisers))
+	for sensor := range net.normalisers {
+		res = append(res, sensor)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,220 @@
+package neuron
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Pipeline represents a net whose actions feed the sensors of another;
+// wired actions carry their raw activations
+type Pipeline interface {
+	GetActions() []string
+	GetChild(int) Pipeline
+	GetUpperChild(int) Pipeline
+	GetLowerChild(int) Pipeline
+	GetSensors() []string
+	GetUpper() NeuralNet
+	GetLower() NeuralNet
+	GetWiring() map[string]string
+	Compute(map[string]float64) (map[string]bool, error)
+	Activate(map[string]float64) (map[string]float64, error)
+	String() string
+}
+
+type pipeline struct {
+	upper   NeuralNet
+	lower   NeuralNet
+	wiring  map[string]string
+	sensors []string
+	actions []string
+}
+
+// NewPipeline instantiate a new pipeline, where wiring maps actions of the
+// upper net onto sensors of the lower one
+func NewPipeline(upper, lower NeuralNet, wiring map[string]string) (Pipeline, error) {
+	if len(wiring) == 0 {
+		return nil, fmt.Errorf("no wire supplied")
+	}
+	upperActions := toSet(upper.GetActions())
+	lowerSensors := toSet(lower.GetSensors())
+
+	wired := make(map[string]bool)
+	copied := make(map[string]string)
+	for action, sensor := range wiring {
+		if !upperActions[action] {
+			return nil, fmt.Errorf("upper net has no action %v", action)
+		}
+		if !lowerSensors[sensor] {
+			return nil, fmt.Errorf("lower net has no sensor %v", sensor)
+		}
+		if wired[sensor] {
+			return nil, fmt.Errorf("sensor %v wired twice", sensor)
+		}
+		wired[sensor] = true
+		copied[action] = sensor
+	}
+
+	sensors := upper.GetSensors()
+	for _, sensor := range lower.GetSensors() {
+		if !wired[sensor] {
+			sensors = append(sensors, sensor)
+		}
+	}
+
+	actions := lower.GetActions()
+	lowerActions := toSet(actions)
+	for _, action := range upper.GetActions() {
+		if _, ok := copied[action]; ok {
+			continue
+		}
+		if lowerActions[action] {
+			return nil, fmt.Errorf("action %v on both nets", action)
+		}
+		actions = append(actions, action)
+	}
+
+	return &pipeline{
+		upper:   upper,
+		lower:   lower,
+		wiring:  copied,
+		sensors: usort(sensors),
+		actions: usort(actions),
+	}, nil
+}
+
+func (pipe pipeline) GetActions() []string {
+	return append([]string{}, pipe.actions...)
+}
+
+func (pipe pipeline) GetSensors() []string {
+	return append([]string{}, pipe.sensors...)
+}
+
+func (pipe pipeline) GetUpper() NeuralNet {
+	return pipe.upper
+}
+
+func (pipe pipeline) GetLower() NeuralNet {
+	return pipe.lower
+}
+
+func (pipe pipeline) GetWiring() map[string]string {
+	res := make(map[string]string)
+	for action, sensor := range pipe.wiring {
+		res[action] = sensor
+	}
+	return res
+}
+
+func (pipe pipeline) GetChild(dev int) Pipeline {
+	child := pipe
+	child.upper = pipe.upper.GetChild(dev)
+	child.lower = pipe.lower.GetChild(dev)
+	return &child
+}
+
+func (pipe pipeline) GetUpperChild(dev int) Pipeline {
+	child := pipe
+	child.upper = pipe.upper.GetChild(dev)
+	return &child
+}
+
+func (pipe pipeline) GetLowerChild(dev int) Pipeline {
+	child := pipe
+	child.lower = pipe.lower.GetChild(dev)
+	return &child
+}
+
+func (pipe pipeline) Compute(incoming map[string]float64) (map[string]bool, error) {
+	activations, err := pipe.Activate(incoming)
+	if err != nil {
+		return nil, err
+	}
+	upper := lastActivation(pipe.upper)
+	lower := lastActivation(pipe.lower)
+	lowerActions := toSet(pipe.lower.GetActions())
+
+	res := make(map[string]bool)
+	for action, value := range activations {
+		if lowerActions[action] {
+			res[action] = lower.fires(value)
+		} else {
+			res[action] = upper.fires(value)
+		}
+	}
+	return res, nil
+}
+
+func (pipe pipeline) Activate(incoming map[string]float64) (map[string]float64, error) {
+	sensors := toSet(pipe.sensors)
+	for key := range incoming {
+		if !sensors[key] {
+			return nil, fmt.Errorf("incoming mismatch sensors")
+		}
+	}
+
+	upperInput := make(map[string]float64)
+	for _, sensor := range pipe.upper.GetSensors() {
+		if value, ok := incoming[sensor]; ok {
+			upperInput[sensor] = value
+		}
+	}
+	upper, err := pipe.upper.Activate(upperInput)
+	if err != nil {
+		return nil, fmt.Errorf("upper net: %v", err)
+	}
+
+	lowerInput := make(map[string]float64)
+	for _, sensor := range pipe.lower.GetSensors() {
+		if value, ok := incoming[sensor]; ok {
+			lowerInput[sensor] = value
+		}
+	}
+	res := make(map[string]float64)
+	for action, value := range upper {
+		if sensor, ok := pipe.wiring[action]; ok {
+			lowerInput[sensor] = value
+		} else {
+			res[action] = value
+		}
+	}
+	lower, err := pipe.lower.Activate(lowerInput)
+	if err != nil {
+		return nil, fmt.Errorf("lower net: %v", err)
+	}
+	for action, value := range lower {
+		res[action] = value
+	}
+	return res, nil
+}
+
+func (pipe pipeline) String() string {
+	var buf strings.Builder
+	actions := make([]string, 0, len(pipe.wiring))
+	for action := range pipe.wiring {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+
+	buf.WriteString("WIRING: ")
+	for i, action := range actions {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		fmt.Fprintf(&buf, "%v -> %v", action, pipe.wiring[action])
+	}
+	buf.WriteString("\nUPPER:\n")
+	buf.WriteString(pipe.upper.String())
+	buf.WriteString("LOWER:\n")
+	buf.WriteString(pipe.lower.String())
+	return buf.String()
+}
+
+func toSet(values []string) map[string]bool {
+	res := make(map[string]bool)
+	for _, value := range values {
+		res[value] = true
+	}
+	return res
+}
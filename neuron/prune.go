@@ -0,0 +1,218 @@
+package neuron
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// PruneOptions tunes Prune
+type PruneOptions struct {
+	// Samples are readings used to find neurons that never fire, and to check
+	// the pruned net computes the same outputs; none means static pruning only
+	Samples []map[string]float64
+	// Threshold zeroes every gene of absolute value up to it before pruning
+	Threshold int
+}
+
+// NeuronRef locates a neuron by layer and position
+type NeuronRef struct {
+	Layer  int `json:"layer"`
+	Neuron int `json:"neuron"`
+}
+
+// PruneReport tells what Prune found and removed; neuron references point
+// into the original net
+type PruneReport struct {
+	Removed      []NeuronRef // dead or unused neurons
+	Constant     []NeuronRef // neurons of constant non-zero output, kept
+	ZeroedGenes  int
+	RemovedGenes int
+	SizeBefore   int // saved size in bytes
+	SizeAfter    int
+}
+
+// Prune return a copy of the net without the hidden neurons that never fire
+// or whose outputs nothing reads, along with the matching downstream genes
+func Prune(net NeuralNet, options PruneOptions) (NeuralNet, PruneReport, error) {
+	var report PruneReport
+	base, err := asNeuralNet(net)
+	if err != nil {
+		return nil, report, err
+	}
+	if base.parts != nil {
+		return nil, report, mergedError("Prune")
+	}
+
+	genes := make([][][]int, len(base.neurons))
+	for i, layer := range base.neurons {
+		genes[i] = make([][]int, len(layer))
+		for j, neu := range layer {
+			genes[i][j] = make([]int, neu.GetSize())
+			for k := range genes[i][j] {
+				gene := neu.GetGene(k)
+				if gene != 0 && gene >= -options.Threshold && gene <= options.Threshold {
+					gene = 0
+					report.ZeroedGenes++
+				}
+				genes[i][j][k] = gene
+			}
+		}
+	}
+
+	// Outputs of every neuron over the samples, before and after zeroing
+	zeroed := *base
+	zeroed.neurons = toLayers(genes)
+	var expected, outputs [][][]float64
+	for _, sample := range options.Samples {
+		current, err := base.activate(sample)
+		if err != nil {
+			return nil, report, err
+		}
+		expected = append(expected, current)
+		current, _ = zeroed.activate(sample)
+		outputs = append(outputs, current)
+	}
+
+	// Original positions of the neurons still alive
+	alive := make([][]int, len(genes))
+	for i := range genes {
+		alive[i] = make([]int, len(genes[i]))
+		for j := range alive[i] {
+			alive[i][j] = j
+		}
+	}
+
+	constant := make(map[NeuronRef]bool)
+	for changed := true; changed; {
+		changed = false
+		for i := 0; i < len(genes)-1; i++ {
+			for j := 0; j < len(genes[i]) && len(genes[i]) > 1; j++ {
+				ref := NeuronRef{i, alive[i][j]}
+				dead, steady := base.isDead(genes, outputs, i, j, ref.Neuron)
+				if !dead && !isUnused(genes[i+1], j) {
+					if steady {
+						constant[ref] = true
+					}
+					continue
+				}
+
+				report.RemovedGenes += len(genes[i][j]) + len(genes[i+1])
+				genes[i] = append(genes[i][:j], genes[i][j+1:]...)
+				alive[i] = append(alive[i][:j], alive[i][j+1:]...)
+				for k := range genes[i+1] {
+					genes[i+1][k] = append(genes[i+1][k][:j], genes[i+1][k][j+1:]...)
+				}
+				report.Removed = append(report.Removed, ref)
+				delete(constant, ref)
+				changed = true
+				j--
+			}
+		}
+	}
+	sort.Slice(report.Removed, func(i, j int) bool {
+		a, b := report.Removed[i], report.Removed[j]
+		return a.Layer < b.Layer || (a.Layer == b.Layer && a.Neuron < b.Neuron)
+	})
+	for i := range alive {
+		for _, j := range alive[i] {
+			if constant[NeuronRef{i, j}] {
+				report.Constant = append(report.Constant, NeuronRef{i, j})
+			}
+		}
+	}
+
+	var frozen [][]bool
+	if base.frozen != nil {
+		frozen = make([][]bool, len(alive))
+		for i := range alive {
+			for _, j := range alive[i] {
+				frozen[i] = append(frozen[i], base.IsFrozen(i, j))
+			}
+		}
+	}
+	pruned, err := base.reshape(base.sensors, base.actions, toLayers(genes), frozen, nil)
+	if err != nil {
+		return nil, report, err
+	}
+
+	for index, sample := range options.Samples {
+		before := expected[index][len(base.neurons)-1]
+		after, _ := pruned.(*neuralnet).activate(sample)
+		for i, value := range after[len(after)-1] {
+			if value != before[i] {
+				return nil, report, fmt.Errorf("sample %v: action %v changed from %v to %v", index, base.actions[i], before[i], value)
+			}
+		}
+	}
+
+	if report.SizeBefore, err = savedSize(base); err != nil {
+		return nil, report, err
+	}
+	if report.SizeAfter, err = savedSize(pruned); err != nil {
+		return nil, report, err
+	}
+	return pruned, report, nil
+}
+
+// isDead tells whether a hidden neuron always outputs zero, and whether it
+// is steady (constant) over the samples; original is its original position
+func (net neuralnet) isDead(genes [][][]int, outputs [][][]float64, layer, index, original int) (bool, bool) {
+	activation := net.GetActivation(layer)
+	if activation.apply(0) == 0 {
+		allZero, nonPositive := true, true
+		for _, gene := range genes[layer][index] {
+			allZero = allZero && gene == 0
+			nonPositive = nonPositive && gene <= 0
+		}
+		if allZero {
+			return true, true
+		}
+		if nonPositive && layer > 0 && (activation == ReLU || activation == Step) && nonNegative(net.GetActivation(layer-1)) {
+			return true, true
+		}
+	}
+
+	if len(outputs) == 0 {
+		return false, false
+	}
+	first := outputs[0][layer][original]
+	for _, current := range outputs[1:] {
+		if current[layer][original] != first {
+			return false, false
+		}
+	}
+	return first == 0, true
+}
+
+func toLayers(genes [][][]int) []Layer {
+	res := make([]Layer, len(genes))
+	for i, layer := range genes {
+		res[i] = make(Layer, len(layer))
+		for j, current := range layer {
+			res[i][j] = neuron(append([]int{}, current...))
+		}
+	}
+	return res
+}
+
+func isUnused(next [][]int, index int) bool {
+	for _, genes := range next {
+		if genes[index] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func nonNegative(activation Activation) bool {
+	return activation == ReLU || activation == Step || activation == Sigmoid
+}
+
+func savedSize(net NeuralNet) (int, error) {
+	var buf bytes.Buffer
+	if err := net.Save(&buf); err != nil {
+		return 0, err
+	}
+	return buf.Len(), nil
+}
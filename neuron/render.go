@@ -0,0 +1,328 @@
+package neuron
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+)
+
+// RenderOptions tunes RenderPNG and RenderSVG
+type RenderOptions struct {
+	// Width and Height are the image size, 800×600 by default
+	Width, Height int
+	// Threshold hides every edge whose gene is of absolute value up to it,
+	// as in WriteDot
+	Threshold int
+	// Input, when set, shades each sensor and neuron by its output for
+	// these readings, relative to its column, and rings fired actions
+	Input map[string]float64
+}
+
+// renderLayout places sensors and neurons in columns, the sensors first
+type renderLayout struct {
+	width, height float64
+	radius        float64
+	columns       [][]renderNode
+	edges         []renderEdge
+}
+
+type renderNode struct {
+	x, y   float64
+	label  string
+	level  float64 // output relative to the column, in [-1, 1]
+	shaded bool
+	frozen bool
+	fired  bool
+}
+
+type renderEdge struct {
+	from, to *renderNode
+	gene     int
+	weight   float64 // relative to the strongest gene drawn, in (0, 1]
+}
+
+var (
+	positiveColour   = color.NRGBA{0x1f, 0x77, 0xb4, 0xff}
+	negativeColour   = color.NRGBA{0xd6, 0x27, 0x28, 0xff}
+	backgroundColour = color.NRGBA{0xff, 0xff, 0xff, 0xff}
+	outlineColour    = color.NRGBA{0x33, 0x33, 0x33, 0xff}
+	frozenColour     = color.NRGBA{0xd3, 0xd3, 0xd3, 0xff}
+	firedColour      = color.NRGBA{0x2c, 0xa0, 0x2c, 0xff}
+)
+
+func layoutNet(net NeuralNet, options RenderOptions) (*renderLayout, error) {
+	res := &renderLayout{width: 800, height: 600}
+	if options.Width > 0 {
+		res.width = float64(options.Width)
+	}
+	if options.Height > 0 {
+		res.height = float64(options.Height)
+	}
+
+	layers := netLayers(net)
+	actions := net.GetActions()
+	res.columns = make([][]renderNode, len(layers)+1)
+	res.columns[0] = make([]renderNode, len(net.GetSensors()))
+	for i, sensor := range net.GetSensors() {
+		res.columns[0][i].label = sensor
+	}
+	for i, layer := range layers {
+		res.columns[i+1] = make([]renderNode, len(layer))
+		for j := range layer {
+			node := &res.columns[i+1][j]
+			node.frozen = net.IsFrozen(i, j)
+			if i == len(layers)-1 {
+				node.label = actions[j]
+			}
+		}
+	}
+
+	tallest := 1
+	for _, column := range res.columns {
+		if len(column) > tallest {
+			tallest = len(column)
+		}
+	}
+	margin := res.width / 8
+	res.radius = math.Min(math.Min(res.height/float64(tallest+1)/3, (res.width-2*margin)/float64(len(res.columns))/4), 20)
+	for i, column := range res.columns {
+		x := margin + (res.width-2*margin)*float64(i)/float64(len(res.columns)-1)
+		for j := range column {
+			column[j].x = x
+			column[j].y = res.height * float64(j+1) / float64(len(column)+1)
+		}
+	}
+
+	if options.Input != nil {
+		if err := res.shade(net, options.Input); err != nil {
+			return nil, err
+		}
+	}
+
+	strongest := strongestGene(layers, options.Threshold)
+	for i, layer := range layers {
+		for j, neu := range layer {
+			for k := 0; k < neu.GetSize(); k++ {
+				if gene := neu.GetGene(k); abs(gene) > options.Threshold {
+					res.edges = append(res.edges, renderEdge{
+						from:   &res.columns[i][k],
+						to:     &res.columns[i+1][j],
+						gene:   gene,
+						weight: float64(abs(gene)) / float64(strongest),
+					})
+				}
+			}
+		}
+	}
+	return res, nil
+}
+
+// shade record the outputs of every column for the given readings
+func (layout *renderLayout) shade(net NeuralNet, input map[string]float64) error {
+	base, err := asNeuralNet(net)
+	if err != nil {
+		return err
+	}
+	fired, err := base.Compute(input)
+	if err != nil {
+		return err
+	}
+	partial, err := base.checkInput(input)
+	if err != nil {
+		return err
+	}
+	for i, sensor := range base.sensors {
+		partial[i] = base.normalise(sensor, partial[i])
+	}
+	outputs := append([][]float64{partial}, base.forward(partial)...)
+
+	for i, column := range layout.columns {
+		strongest := 0.0
+		for _, value := range outputs[i] {
+			strongest = math.Max(strongest, math.Abs(value))
+		}
+		for j := range column {
+			column[j].shaded = true
+			if strongest > 0 {
+				column[j].level = outputs[i][j] / strongest
+			}
+		}
+	}
+	last := layout.columns[len(layout.columns)-1]
+	for j, action := range base.actions {
+		last[j].fired = fired[action]
+	}
+	return nil
+}
+
+// edgeColour return the colour of a gene, more opaque as it grows
+func edgeColour(edge renderEdge) color.NRGBA {
+	res := positiveColour
+	if edge.gene < 0 {
+		res = negativeColour
+	}
+	res.A = uint8(0x40 + edge.weight*0xbf)
+	return res
+}
+
+func edgeWidth(edge renderEdge) float64 {
+	return 0.5 + 3.5*edge.weight
+}
+
+// fillColour return the colour inside a node: its output when shaded, from
+// red for negative to blue for positive through white
+func fillColour(node renderNode) color.NRGBA {
+	if !node.shaded {
+		if node.frozen {
+			return frozenColour
+		}
+		return backgroundColour
+	}
+	target := positiveColour
+	if node.level < 0 {
+		target = negativeColour
+	}
+	mix := func(from, to uint8) uint8 {
+		return uint8(float64(from) + (float64(to)-float64(from))*math.Abs(node.level))
+	}
+	return color.NRGBA{mix(0xff, target.R), mix(0xff, target.G), mix(0xff, target.B), 0xff}
+}
+
+// nodeStroke return the outline of a node, thinner on small nodes so their
+// fill keeps at least half the radius
+func nodeStroke(node renderNode, radius float64) (color.NRGBA, float64) {
+	colour, width := outlineColour, 1.5
+	if node.fired {
+		colour, width = firedColour, 4
+	}
+	return colour, math.Min(width, radius/2)
+}
+
+// RenderImage draw the net: sensors in the first column, then a column per
+// layer, with edges coloured by gene sign and magnitude as in WriteDot.
+// Images carry no text.
+func RenderImage(net NeuralNet, options RenderOptions) (image.Image, error) {
+	layout, err := layoutNet(net, options)
+	if err != nil {
+		return nil, err
+	}
+	canvas := image.NewNRGBA(image.Rect(0, 0, int(layout.width), int(layout.height)))
+	for i := range canvas.Pix {
+		canvas.Pix[i] = 0xff
+	}
+	for _, edge := range layout.edges {
+		drawLine(canvas, edge.from.x, edge.from.y, edge.to.x, edge.to.y, edgeWidth(edge), edgeColour(edge))
+	}
+	for _, column := range layout.columns {
+		for _, node := range column {
+			outline, width := nodeStroke(node, layout.radius)
+			drawDisc(canvas, node.x, node.y, layout.radius, outline)
+			drawDisc(canvas, node.x, node.y, layout.radius-width, fillColour(node))
+		}
+	}
+	return canvas, nil
+}
+
+// RenderPNG write the image RenderImage draws as a PNG
+func RenderPNG(out io.Writer, net NeuralNet, options RenderOptions) error {
+	img, err := RenderImage(net, options)
+	if err != nil {
+		return err
+	}
+	return png.Encode(out, img)
+}
+
+// RenderSVG write the drawing of RenderImage as an SVG document, with
+// sensors and actions labelled and every edge titled by its gene
+func RenderSVG(out io.Writer, net NeuralNet, options RenderOptions) error {
+	layout, err := layoutNet(net, options)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%v" height="%v" viewBox="0 0 %v %v">`+"\n",
+		layout.width, layout.height, layout.width, layout.height)
+	buf.WriteString(`<rect width="100%" height="100%" fill="#ffffff"/>` + "\n")
+
+	buf.WriteString(`<g stroke-linecap="round">` + "\n")
+	for _, edge := range layout.edges {
+		colour := edgeColour(edge)
+		fmt.Fprintf(&buf, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%v" stroke-opacity="%.2f" stroke-width="%.2f"><title>%v</title></line>`+"\n",
+			edge.from.x, edge.from.y, edge.to.x, edge.to.y, hexColour(colour), float64(colour.A)/0xff, edgeWidth(edge), edge.gene)
+	}
+	buf.WriteString("</g>\n")
+
+	last := len(layout.columns) - 1
+	buf.WriteString(`<g font-family="sans-serif" font-size="12">` + "\n")
+	for i, column := range layout.columns {
+		for _, node := range column {
+			stroke, width := nodeStroke(node, layout.radius)
+			fmt.Fprintf(&buf, `<circle cx="%.1f" cy="%.1f" r="%.2f" fill="%v" stroke="%v" stroke-width="%.2f"/>`+"\n",
+				node.x, node.y, layout.radius-width/2, hexColour(fillColour(node)), hexColour(stroke), width)
+			if node.label == "" {
+				continue
+			}
+			x, anchor := node.x-layout.radius-4, "end"
+			if i == last {
+				x, anchor = node.x+layout.radius+4, "start"
+			}
+			fmt.Fprintf(&buf, `<text x="%.1f" y="%.1f" text-anchor="%v" dominant-baseline="middle">`, x, node.y, anchor)
+			xml.EscapeText(&buf, []byte(node.label))
+			buf.WriteString("</text>\n")
+		}
+	}
+	buf.WriteString("</g>\n</svg>\n")
+
+	_, err = out.Write(buf.Bytes())
+	return err
+}
+
+func hexColour(colour color.NRGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", colour.R, colour.G, colour.B)
+}
+
+// blend paint a pixel over the canvas, coverage scaling the colour alpha
+func blend(canvas *image.NRGBA, x, y int, colour color.NRGBA, coverage float64) {
+	if !(image.Point{x, y}.In(canvas.Rect)) || coverage <= 0 {
+		return
+	}
+	alpha := float64(colour.A) / 0xff * math.Min(coverage, 1)
+	offset := canvas.PixOffset(x, y)
+	pix := canvas.Pix[offset : offset+3]
+	for i, value := range []uint8{colour.R, colour.G, colour.B} {
+		pix[i] = uint8(float64(value)*alpha + float64(pix[i])*(1-alpha) + 0.5)
+	}
+}
+
+// drawLine paint a segment of the given width, with smoothed edges
+func drawLine(canvas *image.NRGBA, x0, y0, x1, y1, width float64, colour color.NRGBA) {
+	half := width / 2
+	dx, dy := x1-x0, y1-y0
+	length := dx*dx + dy*dy
+	for y := int(math.Min(y0, y1) - half - 1); y <= int(math.Max(y0, y1)+half+1); y++ {
+		for x := int(math.Min(x0, x1) - half - 1); x <= int(math.Max(x0, x1)+half+1); x++ {
+			px, py := float64(x)+0.5, float64(y)+0.5
+			t := 0.0
+			if length > 0 {
+				t = math.Max(0, math.Min(1, ((px-x0)*dx+(py-y0)*dy)/length))
+			}
+			distance := math.Hypot(px-x0-t*dx, py-y0-t*dy)
+			blend(canvas, x, y, colour, half+0.5-distance)
+		}
+	}
+}
+
+// drawDisc paint a disc, with a smoothed edge
+func drawDisc(canvas *image.NRGBA, cx, cy, radius float64, colour color.NRGBA) {
+	for y := int(cy - radius - 1); y <= int(cy+radius+1); y++ {
+		for x := int(cx - radius - 1); x <= int(cx+radius+1); x++ {
+			distance := math.Hypot(float64(x)+0.5-cx, float64(y)+0.5-cy)
+			blend(canvas, x, y, colour, radius+0.5-distance)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package neuron
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Field documents a sensor or an action
+type Field struct {
+	Unit        string   `json:"unit,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Min         float64  `json:"min,omitempty"` // expected range, checked on sensors when Min < Max
+	Max         float64  `json:"max,omitempty"`
+	Default     *float64 `json:"default,omitempty"` // sensors only: reading used when missing from the input
+}
+
+// Schema documents sensors and actions by name
+type Schema map[string]Field
+
+// HasRange tells whether the field declares an expected range
+func (field Field) HasRange() bool {
+	return field.Min < field.Max
+}
+
+func (field Field) String() string {
+	var parts []string
+	if field.Unit != "" {
+		parts = append(parts, fmt.Sprintf("unit %q", field.Unit))
+	}
+	if field.HasRange() {
+		parts = append(parts, fmt.Sprintf("range [%v, %v]", field.Min, field.Max))
+	}
+	if field.Default != nil {
+		parts = append(parts, fmt.Sprintf("default %v", *field.Default))
+	}
+	if field.Description != "" {
+		parts = append(parts, fmt.Sprintf("doc %q", field.Description))
+	}
+	return strings.Join(parts, " ")
+}
+
+// WithSchema document sensors and actions of the net
+func WithSchema(schema Schema) Option {
+	return func(net *neuralnet) error {
+		sensors := make(map[string]bool)
+		for _, sensor := range net.sensors {
+			sensors[sensor] = true
+		}
+		actions := make(map[string]bool)
+		for _, action := range net.actions {
+			actions[action] = true
+		}
+
+		net.schema = make(Schema)
+		for name, field := range schema {
+			if !sensors[name] && !actions[name] {
+				return fmt.Errorf("schema for unknown field %v", name)
+			}
+			if field.Min > field.Max {
+				return fmt.Errorf("field %v: invalid range [%v, %v]", name, field.Min, field.Max)
+			}
+			if field.Default != nil {
+				if !sensors[name] {
+					return fmt.Errorf("field %v: default on an action", name)
+				}
+				value := *field.Default
+				if field.HasRange() && (value < field.Min || value > field.Max) {
+					return fmt.Errorf("field %v: default %v out of range [%v, %v]", name, value, field.Min, field.Max)
+				}
+				field.Default = &value
+			}
+			net.schema[name] = field
+		}
+		return nil
+	}
+}
+
+func (net neuralnet) GetSchema() Schema {
+	res := make(Schema)
+	for name, field := range net.schema {
+		if field.Default != nil {
+			value := *field.Default
+			field.Default = &value
+		}
+		res[name] = field
+	}
+	return res
+}
+
+func (net neuralnet) documented() []string {
+	res := make([]string, 0, len(net.schema))
+	for name := range net.schema {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,399 @@
+package neuron
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// A saved net may carry optional sections after its neurons. Each one is a
+// four-byte tag, a uint32 body length and the body; four zero bytes close the
+// list, so files holding no section are exactly the original format.
+// Unknown tags are skipped.
+type sectionCodec struct {
+	tag    string
+	encode func(neuralnet) []byte
+	decode func([]byte) (Option, error)
+}
+
+var sectionCodecs = []sectionCodec{
+	{"ACTV", encodeActivations, decodeActivations},
+	{"BNDS", encodeBounds, decodeBounds},
+	{"NORM", encodeNormalisers, decodeNormalisers},
+	{"SCHM", encodeSchema, decodeSchema},
+	{"PART", encodeParts, decodeParts},
+	{"FRZN", encodeFrozen, decodeFrozen},
+	{"META", encodeMetadata, decodeMetadata},
+}
+
+func (net neuralnet) saveSections(buf *bytes.Buffer) {
+	net.writeSections(buf)
+	buf.Write([]byte{0, 0, 0, 0})
+}
+
+func (net neuralnet) writeSections(buf *bytes.Buffer) {
+	for _, codec := range sectionCodecs {
+		if body := codec.encode(net); body != nil {
+			writeSection(buf, codec.tag, body)
+		}
+	}
+}
+
+func writeSection(buf *bytes.Buffer, tag string, body []byte) {
+	var size [4]byte
+	buf.WriteString(tag)
+	binary.BigEndian.PutUint32(size[:], uint32(len(body)))
+	buf.Write(size[:])
+	buf.Write(body)
+}
+
+// sectionOption decode an optional section, nil for unknown tags
+func sectionOption(tag string, body []byte) (Option, error) {
+	for _, codec := range sectionCodecs {
+		if codec.tag == tag {
+			option, err := codec.decode(body)
+			if err != nil {
+				return nil, malformed("section %q: %v", tag, err)
+			}
+			return option, nil
+		}
+	}
+	return nil, nil
+}
+
+func loadSections(input io.Reader) ([]Option, error) {
+	var options []Option
+	var head [4]byte
+
+	for {
+		if _, err := io.ReadFull(input, head[:]); err == io.EOF && options == nil {
+			// Streams missing the tail are accepted, as long as no section
+			// was found: writers of sections always add the tail
+			return options, nil
+		} else if err != nil {
+			return nil, decodeError("section tag", err)
+		}
+		if head == [4]byte{} {
+			return options, nil
+		}
+		tag := string(head[:])
+
+		if _, err := io.ReadFull(input, head[:]); err != nil {
+			return nil, decodeError(fmt.Sprintf("section %q", tag), err)
+		}
+		body, err := readBody(input, binary.BigEndian.Uint32(head[:]))
+		if err != nil {
+			return nil, decodeError(fmt.Sprintf("section %q", tag), err)
+		}
+
+		option, err := sectionOption(tag, body)
+		if err != nil {
+			return nil, err
+		}
+		if option != nil {
+			options = append(options, option)
+		}
+	}
+}
+
+func encodeActivations(net neuralnet) []byte {
+	if !net.customActivations() {
+		return nil
+	}
+	body := make([]byte, len(net.activations))
+	for i, activation := range net.activations {
+		body[i] = byte(activation)
+	}
+	return body
+}
+
+func decodeActivations(body []byte) (Option, error) {
+	activations := make([]Activation, len(body))
+	for i, value := range body {
+		activations[i] = Activation(value)
+	}
+	return WithActivations(activations...), nil
+}
+
+func encodeBounds(net neuralnet) []byte {
+	if net.bounds == nil {
+		return nil
+	}
+	body := make([]byte, 9*len(net.bounds))
+	for i, bounds := range net.bounds {
+		binary.BigEndian.PutUint32(body[9*i:], uint32(int32(bounds.Min)))
+		binary.BigEndian.PutUint32(body[9*i+4:], uint32(int32(bounds.Max)))
+		body[9*i+8] = byte(bounds.Mode)
+	}
+	return body
+}
+
+func decodeBounds(body []byte) (Option, error) {
+	if len(body)%9 != 0 {
+		return nil, fmt.Errorf("unexpected bounds size %v", len(body))
+	}
+	bounds := make([]Bounds, len(body)/9)
+	for i := range bounds {
+		bounds[i] = Bounds{
+			Min:  int(int32(binary.BigEndian.Uint32(body[9*i:]))),
+			Max:  int(int32(binary.BigEndian.Uint32(body[9*i+4:]))),
+			Mode: BoundsMode(body[9*i+8]),
+		}
+	}
+	return WithBounds(bounds...), nil
+}
+
+func encodeNormalisers(net neuralnet) []byte {
+	if len(net.normalisers) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	var current [8]byte
+	for _, sensor := range net.normalised() {
+		norm := net.normalisers[sensor]
+		buf.WriteString(sensor)
+		buf.WriteByte(0x00)
+		buf.WriteByte(byte(norm.Method))
+		if norm.Clip {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+		for _, value := range []float64{norm.Min, norm.Max, norm.Mean, norm.Std} {
+			binary.BigEndian.PutUint64(current[:], math.Float64bits(value))
+			buf.Write(current[:])
+		}
+	}
+	return buf.Bytes()
+}
+
+func decodeNormalisers(body []byte) (Option, error) {
+	normalisers := make(map[string]Normaliser)
+	for len(body) > 0 {
+		end := bytes.IndexByte(body, 0x00)
+		if end < 0 || len(body) < end+35 {
+			return nil, fmt.Errorf("truncated normaliser")
+		}
+		sensor := string(body[:end])
+		body = body[end+1:]
+		norm := Normaliser{Method: NormMethod(body[0]), Clip: body[1] != 0}
+		values := []*float64{&norm.Min, &norm.Max, &norm.Mean, &norm.Std}
+		for i, value := range values {
+			*value = math.Float64frombits(binary.BigEndian.Uint64(body[2+8*i:]))
+		}
+		normalisers[sensor] = norm
+		body = body[34:]
+	}
+	return WithNormalisers(normalisers), nil
+}
+
+func encodeSchema(net neuralnet) []byte {
+	if len(net.schema) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	var current [8]byte
+	for _, name := range net.documented() {
+		field := net.schema[name]
+		for _, str := range []string{name, field.Unit, field.Description} {
+			buf.WriteString(str)
+			buf.WriteByte(0x00)
+		}
+		value := 0.0
+		if field.Default == nil {
+			buf.WriteByte(0)
+		} else {
+			buf.WriteByte(1)
+			value = *field.Default
+		}
+		for _, value := range []float64{field.Min, field.Max, value} {
+			binary.BigEndian.PutUint64(current[:], math.Float64bits(value))
+			buf.Write(current[:])
+		}
+	}
+	return buf.Bytes()
+}
+
+func decodeSchema(body []byte) (Option, error) {
+	schema := make(Schema)
+	for len(body) > 0 {
+		var strs [3]string
+		for i := range strs {
+			end := bytes.IndexByte(body, 0x00)
+			if end < 0 {
+				return nil, fmt.Errorf("truncated field")
+			}
+			strs[i] = string(body[:end])
+			body = body[end+1:]
+		}
+		if len(body) < 25 {
+			return nil, fmt.Errorf("truncated field %v", strs[0])
+		}
+		field := Field{
+			Unit:        strs[1],
+			Description: strs[2],
+			Min:         math.Float64frombits(binary.BigEndian.Uint64(body[1:])),
+			Max:         math.Float64frombits(binary.BigEndian.Uint64(body[9:])),
+		}
+		if body[0] != 0 {
+			value := math.Float64frombits(binary.BigEndian.Uint64(body[17:]))
+			field.Default = &value
+		}
+		schema[strs[0]] = field
+		body = body[25:]
+	}
+	return WithSchema(schema), nil
+}
+
+func encodeParts(net neuralnet) []byte {
+	if net.parts == nil {
+		return nil
+	}
+	var buf bytes.Buffer
+	var current [4]byte
+	for _, part := range net.parts {
+		for _, names := range [][]string{part.sensors, part.actions} {
+			binary.BigEndian.PutUint32(current[:], uint32(len(names)))
+			buf.Write(current[:])
+			for _, name := range names {
+				buf.WriteString(name)
+				buf.WriteByte(0x00)
+			}
+		}
+		binary.BigEndian.PutUint32(current[:], uint32(len(part.widths)))
+		buf.Write(current[:])
+		for _, width := range part.widths {
+			binary.BigEndian.PutUint32(current[:], uint32(width))
+			buf.Write(current[:])
+		}
+	}
+	return buf.Bytes()
+}
+
+func decodeParts(body []byte) (Option, error) {
+	input := bytes.NewReader(body)
+	var parts []part
+	for input.Len() > 0 {
+		var current part
+		for _, names := range []*[]string{&current.sensors, &current.actions} {
+			size, err := readUint32(input)
+			if err != nil {
+				return nil, err
+			}
+			for i := uint32(0); i < size; i++ {
+				name, err := readString(input)
+				if err != nil {
+					return nil, err
+				}
+				*names = append(*names, name)
+			}
+		}
+		size, err := readUint32(input)
+		if err != nil {
+			return nil, err
+		}
+		for i := uint32(0); i < size; i++ {
+			width, err := readUint32(input)
+			if err != nil {
+				return nil, err
+			}
+			current.widths = append(current.widths, int(width))
+		}
+		parts = append(parts, current)
+	}
+	return withParts(parts), nil
+}
+
+func readUint32(input io.Reader) (uint32, error) {
+	var buf [4]byte
+	if _, err := io.ReadFull(input, buf[:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(buf[:]), nil
+}
+
+func readString(input io.ByteReader) (string, error) {
+	var buf bytes.Buffer
+	for {
+		current, err := input.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		if current == 0x00 {
+			return buf.String(), nil
+		}
+		buf.WriteByte(current)
+	}
+}
+
+func encodeFrozen(net neuralnet) []byte {
+	if !net.anyFrozen() {
+		return nil
+	}
+	var buf bytes.Buffer
+	var current [4]byte
+	for _, flags := range net.frozen {
+		binary.BigEndian.PutUint32(current[:], uint32(len(flags)))
+		buf.Write(current[:])
+		for _, flag := range flags {
+			if flag {
+				buf.WriteByte(1)
+			} else {
+				buf.WriteByte(0)
+			}
+		}
+	}
+	return buf.Bytes()
+}
+
+func decodeFrozen(body []byte) (Option, error) {
+	input := bytes.NewReader(body)
+	var frozen [][]bool
+	for input.Len() > 0 {
+		size, err := readUint32(input)
+		if err != nil {
+			return nil, err
+		}
+		if int64(size) > int64(input.Len()) {
+			return nil, fmt.Errorf("truncated frozen flags")
+		}
+		flags := make([]bool, size)
+		for i := range flags {
+			value, _ := input.ReadByte()
+			flags[i] = value != 0
+		}
+		frozen = append(frozen, flags)
+	}
+	return withFrozen(frozen), nil
+}
+
+func encodeMetadata(net neuralnet) []byte {
+	if len(net.metadata) == 0 {
+		return nil
+	}
+	var pairs []string
+	for _, key := range net.metadataKeys() {
+		pairs = append(pairs, key, net.metadata[key])
+	}
+	return encodeStrings(pairs)
+}
+
+func decodeMetadata(body []byte) (Option, error) {
+	pairs, err := decodeStrings(body)
+	if err != nil {
+		return nil, err
+	}
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("key %q without value", pairs[len(pairs)-1])
+	}
+	metadata := make(Metadata)
+	for i := 0; i < len(pairs); i += 2 {
+		if _, ok := metadata[pairs[i]]; ok {
+			return nil, fmt.Errorf("repeated key %q", pairs[i])
+		}
+		metadata[pairs[i]] = pairs[i+1]
+	}
+	return WithMetadata(metadata), nil
+}
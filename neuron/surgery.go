@@ -0,0 +1,221 @@
+package neuron
+
+import (
+	"fmt"
+	"sort"
+)
+
+// AddSensor return a copy of the net reading a further sensor; first layer
+// neurons get a zero gene for it, so the net behaves as before, which first
+// layer bounds must allow
+func AddSensor(net NeuralNet, sensor string) (NeuralNet, error) {
+	base, err := asNeuralNet(net)
+	if err != nil {
+		return nil, err
+	}
+	index := sort.SearchStrings(base.sensors, sensor)
+	if index < len(base.sensors) && base.sensors[index] == sensor {
+		return nil, fmt.Errorf("sensor %v already exists", sensor)
+	}
+
+	if bounds := base.GetBounds(0); !bounds.Contains(0) {
+		return nil, fmt.Errorf("layer 0 bounds %v exclude the neutral gene 0", bounds)
+	}
+	neurons := base.copyNeurons()
+	for j, neu := range neurons[0] {
+		neurons[0][j] = insertGene(neu, index, 0)
+	}
+	// Parts read sensors by name, so they still apply
+	return base.reshape(append(base.GetSensors(), sensor), base.actions, neurons, base.frozen, base.parts)
+}
+
+// RemoveSensor return a copy of the net no longer reading sensor
+func RemoveSensor(net NeuralNet, sensor string) (NeuralNet, error) {
+	base, err := asNeuralNet(net)
+	if err != nil {
+		return nil, err
+	}
+	index := sort.SearchStrings(base.sensors, sensor)
+	if index == len(base.sensors) || base.sensors[index] != sensor {
+		return nil, fmt.Errorf("unknown sensor %v", sensor)
+	}
+
+	neurons := base.copyNeurons()
+	for j, neu := range neurons[0] {
+		neurons[0][j] = removeGene(neu, index)
+	}
+	sensors := append(base.GetSensors()[:index], base.sensors[index+1:]...)
+	var parts []part
+	for _, current := range base.parts {
+		current.sensors = without(current.sensors, sensor)
+		parts = append(parts, current)
+	}
+	return base.reshape(sensors, base.actions, neurons, base.frozen, parts)
+}
+
+// AddAction return a copy of the net triggering a further action, decided by
+// a new last layer neuron drawn from initialiser (nil for the default one)
+func AddAction(net NeuralNet, action string, initialiser Initialiser) (NeuralNet, error) {
+	base, err := asNeuralNet(net)
+	if err != nil {
+		return nil, err
+	}
+	if base.parts != nil {
+		return nil, mergedError("AddAction")
+	}
+	index := sort.SearchStrings(base.actions, action)
+	if index < len(base.actions) && base.actions[index] == action {
+		return nil, fmt.Errorf("action %v already exists", action)
+	}
+
+	last := len(base.neurons) - 1
+	size := base.neurons[last][0].GetSize()
+	neu, err := newRandomNeuron(NeuronSpec{Size: size, FanOut: len(base.actions) + 1, Initialiser: initialiser})
+	if err != nil {
+		return nil, err
+	}
+	neu = boundNeuron(neu, base.GetBounds(last))
+
+	neurons := base.copyNeurons()
+	layer := append(Layer{}, neurons[last][:index]...)
+	layer = append(layer, neu)
+	neurons[last] = append(layer, neurons[last][index:]...)
+	frozen := base.copyFrozen()
+	if frozen != nil {
+		flags := append([]bool{}, frozen[last][:index]...)
+		frozen[last] = append(append(flags, false), frozen[last][index:]...)
+	}
+	return base.reshape(base.sensors, append(base.GetActions(), action), neurons, frozen, nil)
+}
+
+// RemoveAction return a copy of the net no longer triggering action
+func RemoveAction(net NeuralNet, action string) (NeuralNet, error) {
+	base, err := asNeuralNet(net)
+	if err != nil {
+		return nil, err
+	}
+	if base.parts != nil {
+		return nil, mergedError("RemoveAction")
+	}
+	index := sort.SearchStrings(base.actions, action)
+	if index == len(base.actions) || base.actions[index] != action {
+		return nil, fmt.Errorf("unknown action %v", action)
+	}
+
+	last := len(base.neurons) - 1
+	neurons := base.copyNeurons()
+	neurons[last] = append(neurons[last][:index], neurons[last][index+1:]...)
+	actions := append(base.GetActions()[:index], base.actions[index+1:]...)
+	frozen := base.copyFrozen()
+	if frozen != nil {
+		frozen[last] = append(frozen[last][:index], frozen[last][index+1:]...)
+	}
+	return base.reshape(base.sensors, actions, neurons, frozen, nil)
+}
+
+// mergedError reports an operation that would break the parts of a merged
+// net
+func mergedError(operation string) error {
+	return fmt.Errorf("%v does not support merged nets, split them first", operation)
+}
+
+func asNeuralNet(net NeuralNet) (*neuralnet, error) {
+	base, ok := net.(*neuralnet)
+	if !ok {
+		return nil, fmt.Errorf("unexpected net type %T", net)
+	}
+	return base, nil
+}
+
+func (net neuralnet) copyNeurons() []Layer {
+	res := make([]Layer, len(net.neurons))
+	for i, layer := range net.neurons {
+		res[i] = append(Layer{}, layer...)
+	}
+	return res
+}
+
+func (net neuralnet) copyFrozen() [][]bool {
+	if net.frozen == nil {
+		return nil
+	}
+	res := make([][]bool, len(net.frozen))
+	for i, flags := range net.frozen {
+		res[i] = append([]bool{}, flags...)
+	}
+	return res
+}
+
+// reshape build a net of the given structure carrying over the settings of
+// this one that still apply, with the given frozen flags and merged parts
+func (net neuralnet) reshape(sensors, actions []string, neurons []Layer, frozen [][]bool, parts []part) (NeuralNet, error) {
+	built, err := NewNeuralNet(sensors, actions, neurons)
+	if err != nil {
+		return nil, err
+	}
+	res := built.(*neuralnet)
+	if parts != nil {
+		if err := withParts(parts)(res); err != nil {
+			return nil, err
+		}
+	}
+	res.activations = net.activations
+	res.bounds = net.bounds
+	res.frozen = frozen
+	res.metadata = net.metadata
+
+	names := make(map[string]bool)
+	for _, name := range append(res.GetSensors(), res.actions...) {
+		names[name] = true
+	}
+	if net.normalisers != nil {
+		res.normalisers = make(map[string]Normaliser)
+		for sensor, norm := range net.normalisers {
+			if names[sensor] {
+				res.normalisers[sensor] = norm
+			}
+		}
+	}
+	if net.schema != nil {
+		res.schema = make(Schema)
+		for name, field := range net.schema {
+			if names[name] {
+				res.schema[name] = field
+			}
+		}
+	}
+	return res, nil
+}
+
+// without return a copy of values but name
+func without(values []string, name string) []string {
+	var res []string
+	for _, value := range values {
+		if value != name {
+			res = append(res, value)
+		}
+	}
+	return res
+}
+
+func insertGene(neu Neuron, index, gene int) Neuron {
+	res := make(neuron, 0, neu.GetSize()+1)
+	for i := 0; i < index; i++ {
+		res = append(res, neu.GetGene(i))
+	}
+	res = append(res, gene)
+	for i := index; i < neu.GetSize(); i++ {
+		res = append(res, neu.GetGene(i))
+	}
+	return res
+}
+
+func removeGene(neu Neuron, index int) Neuron {
+	res := make(neuron, 0, neu.GetSize()-1)
+	for i := 0; i < neu.GetSize(); i++ {
+		if i != index {
+			res = append(res, neu.GetGene(i))
+		}
+	}
+	return res
+}
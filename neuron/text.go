@@ -0,0 +1,567 @@
+package neuron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The text format is the one String emits, one statement per line:
+//
+//	SENSORS: distance, "speed, km/h"
+//	ACTIONS: brake, steer
+//	ACTIVATIONS: tanh, sigmoid
+//	BOUNDS 0: [-2000, 2000] reflect
+//	FIELD distance: unit "m" range [0, 100] default 50 doc "to the wall"
+//	PART 0: distance -> brake; widths 2, 1
+//	FROZEN 0: 1
+//	NORMALISE distance: minmax [0, 100] clip
+//	META generation: "12"
+//	NEURONS:
+//	120 -45
+//	-7 300
+//
+//	1000 -1000
+//	500 500
+//
+//	-----
+//
+// Statements before NEURONS may come in any order, all but SENSORS and
+// ACTIONS being optional. Names holding any of `,:;"`, "->", tabs,
+// line breaks or surrounding spaces are written as Go quoted strings. Each
+// line after NEURONS holds the decimal genes of one neuron, a blank line
+// closes each layer and "-----" closes the net. Lines starting with "#" are
+// comments.
+
+// ParseNet read a net back from its text format
+func ParseNet(text string) (NeuralNet, error) {
+	var parser textParser
+	lines := strings.Split(text, "\n")
+	number := 0
+	for number < len(lines) {
+		line := strings.TrimRight(lines[number], " \t\r")
+		number++
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if line == "NEURONS:" {
+			read, err := parser.neurons(lines[number:])
+			if err != nil {
+				return nil, fmt.Errorf("line %v: %v", number+read, err)
+			}
+			number += read
+			break
+		}
+		if err := parser.statement(line); err != nil {
+			return nil, fmt.Errorf("line %v: %v", number, err)
+		}
+	}
+	for ; number < len(lines); number++ {
+		if line := strings.TrimSpace(lines[number]); line != "" && !strings.HasPrefix(line, "#") {
+			return nil, fmt.Errorf("line %v: unexpected text after the net", number+1)
+		}
+	}
+	if parser.layers == nil {
+		return nil, fmt.Errorf("missing NEURONS")
+	}
+
+	net, err := NewNeuralNet(parser.sensors, parser.actions, parser.layers, parser.options()...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid net: %w", err)
+	}
+	return net, nil
+}
+
+type textParser struct {
+	seen        map[string]bool
+	sensors     []string
+	actions     []string
+	activations []Activation
+	bounds      map[int]Bounds
+	schema      Schema
+	parts       map[int]part
+	frozen      []NeuronRef
+	normalisers map[string]Normaliser
+	metadata    Metadata
+	layers      []Layer
+}
+
+func (parser *textParser) statement(line string) error {
+	colon := strings.IndexByte(line, ':')
+	keyword := line
+	if space := strings.IndexByte(line, ' '); space >= 0 && (colon < 0 || space < colon) {
+		keyword = line[:space]
+	} else if colon >= 0 {
+		keyword = line[:colon]
+	}
+	scan := &textScanner{text: line[len(keyword):]}
+
+	if parser.seen == nil {
+		parser.seen = make(map[string]bool)
+	}
+	switch keyword {
+	case "SENSORS", "ACTIONS", "ACTIVATIONS":
+		if parser.seen[keyword] {
+			return fmt.Errorf("repeated %v", keyword)
+		}
+		parser.seen[keyword] = true
+		if err := scan.expect(":"); err != nil {
+			return err
+		}
+		names, err := scan.names()
+		if err != nil {
+			return err
+		}
+		switch keyword {
+		case "SENSORS":
+			parser.sensors = names
+		case "ACTIONS":
+			parser.actions = names
+		default:
+			for _, name := range names {
+				activation, err := ParseActivation(name)
+				if err != nil {
+					return err
+				}
+				parser.activations = append(parser.activations, activation)
+			}
+		}
+
+	case "BOUNDS":
+		index, err := scan.index(func(i int) bool { _, ok := parser.bounds[i]; return ok })
+		if err != nil {
+			return err
+		}
+		var bounds Bounds
+		if bounds.Min, bounds.Max, err = scan.intRange(); err != nil {
+			return err
+		}
+		if err := bounds.Mode.UnmarshalText([]byte(scan.word())); err != nil {
+			return err
+		}
+		if parser.bounds == nil {
+			parser.bounds = make(map[int]Bounds)
+		}
+		parser.bounds[index] = bounds
+
+	case "FIELD":
+		name, err := scan.label()
+		if err != nil {
+			return err
+		}
+		if _, ok := parser.schema[name]; ok {
+			return fmt.Errorf("repeated field %v", name)
+		}
+		field, err := scan.field()
+		if err != nil {
+			return err
+		}
+		if parser.schema == nil {
+			parser.schema = make(Schema)
+		}
+		parser.schema[name] = field
+
+	case "PART":
+		index, err := scan.index(func(i int) bool { _, ok := parser.parts[i]; return ok })
+		if err != nil {
+			return err
+		}
+		var current part
+		if current.sensors, err = scan.names(); err != nil {
+			return err
+		}
+		if err := scan.expect("->"); err != nil {
+			return err
+		}
+		if current.actions, err = scan.names(); err != nil {
+			return err
+		}
+		if err := scan.expect(";"); err != nil {
+			return err
+		}
+		if scan.word() != "widths" {
+			return fmt.Errorf("expected widths")
+		}
+		if current.widths, err = scan.ints(); err != nil {
+			return err
+		}
+		if parser.parts == nil {
+			parser.parts = make(map[int]part)
+		}
+		parser.parts[index] = current
+
+	case "FROZEN":
+		layer, err := scan.index(nil)
+		if err != nil {
+			return err
+		}
+		indices, err := scan.ints()
+		if err != nil {
+			return err
+		}
+		for _, index := range indices {
+			parser.frozen = append(parser.frozen, NeuronRef{Layer: layer, Neuron: index})
+		}
+
+	case "NORMALISE":
+		name, err := scan.label()
+		if err != nil {
+			return err
+		}
+		if _, ok := parser.normalisers[name]; ok {
+			return fmt.Errorf("repeated normaliser %v", name)
+		}
+		norm, err := scan.normaliser()
+		if err != nil {
+			return err
+		}
+		if parser.normalisers == nil {
+			parser.normalisers = make(map[string]Normaliser)
+		}
+		parser.normalisers[name] = norm
+
+	case "META":
+		key, err := scan.label()
+		if err != nil {
+			return err
+		}
+		if _, ok := parser.metadata[key]; ok {
+			return fmt.Errorf("repeated metadata %v", key)
+		}
+		value, err := scan.quoted()
+		if err != nil {
+			return err
+		}
+		if parser.metadata == nil {
+			parser.metadata = make(Metadata)
+		}
+		parser.metadata[key] = value
+
+	default:
+		return fmt.Errorf("unknown statement %q", keyword)
+	}
+
+	if !scan.done() {
+		return fmt.Errorf("unexpected %q", strings.TrimSpace(scan.text[scan.pos:]))
+	}
+	return nil
+}
+
+// neurons read the layers, returning the number of lines consumed
+func (parser *textParser) neurons(lines []string) (int, error) {
+	parser.layers = []Layer{}
+	var layer Layer
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "#"):
+		case line == "" || line == "-----":
+			if layer != nil {
+				parser.layers = append(parser.layers, layer)
+				layer = nil
+			}
+			if line == "-----" {
+				return i + 1, nil
+			}
+		default:
+			fields := strings.Fields(line)
+			neu := make(neuron, len(fields))
+			for j, field := range fields {
+				gene, err := strconv.ParseInt(field, 10, 32)
+				if err != nil {
+					return i + 1, fmt.Errorf("gene %v: %v", j, err)
+				}
+				neu[j] = int(gene)
+			}
+			layer = append(layer, neu)
+		}
+	}
+	return len(lines), fmt.Errorf("missing -----")
+}
+
+func (parser *textParser) options() []Option {
+	var options []Option
+	if parser.activations != nil {
+		options = append(options, WithActivations(parser.activations...))
+	}
+	if parser.bounds != nil {
+		bounds := make([]Bounds, len(parser.layers))
+		for i := range bounds {
+			bounds[i] = DefaultBounds
+		}
+		for i, current := range parser.bounds {
+			if i >= len(bounds) {
+				return append(options, fail(fmt.Errorf("bounds for missing layer %v", i)))
+			}
+			bounds[i] = current
+		}
+		options = append(options, WithBounds(bounds...))
+	}
+	if parser.normalisers != nil {
+		options = append(options, WithNormalisers(parser.normalisers))
+	}
+	if parser.schema != nil {
+		options = append(options, WithSchema(parser.schema))
+	}
+	if parser.frozen != nil {
+		options = append(options, WithFrozenNeurons(parser.frozen...))
+	}
+	if parser.parts != nil {
+		parts := make([]part, len(parser.parts))
+		for i := range parts {
+			current, ok := parser.parts[i]
+			if !ok {
+				return append(options, fail(fmt.Errorf("missing part %v", i)))
+			}
+			parts[i] = current
+		}
+		options = append(options, withParts(parts))
+	}
+	if parser.metadata != nil {
+		options = append(options, WithMetadata(parser.metadata))
+	}
+	return options
+}
+
+func fail(err error) Option {
+	return func(*neuralnet) error {
+		return err
+	}
+}
+
+// textScanner reads the tokens of a statement
+type textScanner struct {
+	text string
+	pos  int
+}
+
+func (scan *textScanner) skipSpaces() {
+	for scan.pos < len(scan.text) && (scan.text[scan.pos] == ' ' || scan.text[scan.pos] == '\t') {
+		scan.pos++
+	}
+}
+
+func (scan *textScanner) done() bool {
+	scan.skipSpaces()
+	return scan.pos == len(scan.text)
+}
+
+func (scan *textScanner) peek(lit string) bool {
+	scan.skipSpaces()
+	return strings.HasPrefix(scan.text[scan.pos:], lit)
+}
+
+func (scan *textScanner) expect(lit string) error {
+	if !scan.peek(lit) {
+		return fmt.Errorf("expected %q", lit)
+	}
+	scan.pos += len(lit)
+	return nil
+}
+
+// word read up to the next space or delimiter
+func (scan *textScanner) word() string {
+	scan.skipSpaces()
+	start := scan.pos
+	for scan.pos < len(scan.text) && !strings.ContainsRune(" \t,]:;", rune(scan.text[scan.pos])) {
+		scan.pos++
+	}
+	return scan.text[start:scan.pos]
+}
+
+func (scan *textScanner) quoted() (string, error) {
+	scan.skipSpaces()
+	if !scan.peek(`"`) {
+		return "", fmt.Errorf("expected a quoted string")
+	}
+	for end := scan.pos + 1; end < len(scan.text); end++ {
+		switch scan.text[end] {
+		case '\\':
+			end++
+		case '"':
+			value, err := strconv.Unquote(scan.text[scan.pos : end+1])
+			scan.pos = end + 1
+			return value, err
+		}
+	}
+	return "", fmt.Errorf("unterminated string")
+}
+
+// name read a quoted or a bare name
+func (scan *textScanner) name() (string, error) {
+	if scan.peek(`"`) {
+		return scan.quoted()
+	}
+	start := scan.pos
+	for scan.pos < len(scan.text) && !strings.ContainsRune(",:;\"", rune(scan.text[scan.pos])) &&
+		!strings.HasPrefix(scan.text[scan.pos:], "->") {
+		scan.pos++
+	}
+	name := strings.TrimSpace(scan.text[start:scan.pos])
+	if name == "" {
+		return "", fmt.Errorf("expected a name")
+	}
+	return name, nil
+}
+
+// names read a comma-separated list of names, possibly empty
+func (scan *textScanner) names() ([]string, error) {
+	var res []string
+	if scan.done() || scan.peek("->") || scan.peek(";") {
+		return res, nil
+	}
+	for {
+		name, err := scan.name()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, name)
+		if !scan.peek(",") {
+			return res, nil
+		}
+		scan.pos++
+	}
+}
+
+// label read the name before the colon of a statement
+func (scan *textScanner) label() (string, error) {
+	name, err := scan.name()
+	if err != nil {
+		return "", err
+	}
+	return name, scan.expect(":")
+}
+
+// index read the number before the colon of a statement
+func (scan *textScanner) index(repeated func(int) bool) (int, error) {
+	value, err := strconv.Atoi(scan.word())
+	if err != nil {
+		return 0, err
+	}
+	if value < 0 {
+		return 0, fmt.Errorf("negative index %v", value)
+	}
+	if repeated != nil && repeated(value) {
+		return 0, fmt.Errorf("repeated index %v", value)
+	}
+	return value, scan.expect(":")
+}
+
+func (scan *textScanner) ints() ([]int, error) {
+	var res []int
+	for {
+		value, err := strconv.Atoi(scan.word())
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, value)
+		if !scan.peek(",") {
+			return res, nil
+		}
+		scan.pos++
+	}
+}
+
+func (scan *textScanner) float() (float64, error) {
+	return strconv.ParseFloat(scan.word(), 64)
+}
+
+// floatRange read "[min, max]"
+func (scan *textScanner) floatRange() (float64, float64, error) {
+	if err := scan.expect("["); err != nil {
+		return 0, 0, err
+	}
+	min, err := scan.float()
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := scan.expect(","); err != nil {
+		return 0, 0, err
+	}
+	max, err := scan.float()
+	if err != nil {
+		return 0, 0, err
+	}
+	return min, max, scan.expect("]")
+}
+
+func (scan *textScanner) intRange() (int, int, error) {
+	min, max, err := scan.floatRange()
+	if err != nil {
+		return 0, 0, err
+	}
+	if min != float64(int(min)) || max != float64(int(max)) {
+		return 0, 0, fmt.Errorf("expected integer bounds, got [%v, %v]", min, max)
+	}
+	return int(min), int(max), nil
+}
+
+// field read what Field.String emits
+func (scan *textScanner) field() (Field, error) {
+	var field Field
+	var err error
+	for !scan.done() {
+		switch word := scan.word(); word {
+		case "unit":
+			field.Unit, err = scan.quoted()
+		case "doc":
+			field.Description, err = scan.quoted()
+		case "range":
+			field.Min, field.Max, err = scan.floatRange()
+		case "default":
+			var value float64
+			value, err = scan.float()
+			field.Default = &value
+		default:
+			err = fmt.Errorf("unexpected %q", word)
+		}
+		if err != nil {
+			return field, err
+		}
+	}
+	return field, nil
+}
+
+// normaliser read what Normaliser.String emits
+func (scan *textScanner) normaliser() (Normaliser, error) {
+	var norm Normaliser
+	var err error
+	if err = norm.Method.UnmarshalText([]byte(scan.word())); err != nil {
+		return norm, err
+	}
+	if norm.Method == Standard {
+		if norm.Mean, err = scan.float(); err != nil {
+			return norm, err
+		}
+		if norm.Std, err = scan.float(); err != nil {
+			return norm, err
+		}
+	}
+	if scan.peek("[") {
+		if norm.Min, norm.Max, err = scan.floatRange(); err != nil {
+			return norm, err
+		}
+	}
+	if scan.peek("clip") {
+		scan.word()
+		norm.Clip = true
+	}
+	return norm, nil
+}
+
+// quoteName quote names the text format could not read back bare
+func quoteName(name string) string {
+	if name == "" || name != strings.TrimSpace(name) || strings.ContainsAny(name, ",:;\"\t\r\n") ||
+		strings.Contains(name, "->") {
+		return strconv.Quote(name)
+	}
+	return name
+}
+
+func joinNames(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quoteName(name)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,165 @@
+package neuron
+
+import "fmt"
+
+// Trunk holds the first layers of a net, to be reused under a new head
+type Trunk struct {
+	Sensors     []string
+	Layers      []Layer
+	Activations []Activation
+	Bounds      []Bounds // nil when the net declares none
+	Normalisers map[string]Normaliser
+	Schema      Schema // sensor fields only
+}
+
+// Extract return the first count layers of the net
+func Extract(net NeuralNet, count int) (Trunk, error) {
+	base, err := asNeuralNet(net)
+	if err != nil {
+		return Trunk{}, err
+	}
+	if count <= 0 || count > len(base.neurons) {
+		return Trunk{}, fmt.Errorf("cannot extract %v layers out of %v", count, len(base.neurons))
+	}
+
+	trunk := Trunk{
+		Sensors:     base.GetSensors(),
+		Layers:      base.copyNeurons()[:count],
+		Activations: make([]Activation, count),
+		Normalisers: make(map[string]Normaliser),
+		Schema:      make(Schema),
+	}
+	for i := range trunk.Activations {
+		trunk.Activations[i] = base.GetActivation(i)
+	}
+	if base.bounds != nil {
+		trunk.Bounds = append([]Bounds{}, base.bounds[:count]...)
+	}
+	for sensor, norm := range base.normalisers {
+		trunk.Normalisers[sensor] = norm
+	}
+	sensors := toSet(base.sensors)
+	for name, field := range base.GetSchema() {
+		if sensors[name] {
+			trunk.Schema[name] = field
+		}
+	}
+	return trunk, nil
+}
+
+// Attach build a net reading the outputs of the trunk through a new random
+// head; the head’s sensors are ignored, and the trunk neurons come frozen
+func Attach(trunk Trunk, head Topology, options ...Option) (NeuralNet, error) {
+	if len(trunk.Layers) == 0 {
+		return nil, fmt.Errorf("empty trunk")
+	}
+	layers, activations, err := head.buildLayers(len(trunk.Layers[len(trunk.Layers)-1]))
+	if err != nil {
+		return nil, err
+	}
+
+	frozen := make([]int, len(trunk.Layers))
+	for i := range frozen {
+		frozen[i] = i
+	}
+	activations = append(append([]Activation{}, trunk.Activations...), activations...)
+	layers = append(append([]Layer{}, trunk.Layers...), layers...)
+
+	base := []Option{
+		WithActivations(activations...),
+		WithNormalisers(trunk.Normalisers),
+		WithSchema(trunk.Schema),
+		WithFrozenLayers(frozen...),
+	}
+	if trunk.Bounds != nil {
+		// The head abides by the bounds of the trunk’s last layer
+		bounds := append([]Bounds{}, trunk.Bounds...)
+		last := trunk.Bounds[len(trunk.Bounds)-1]
+		for _, layer := range layers[len(trunk.Layers):] {
+			for j, neu := range layer {
+				layer[j] = boundNeuron(neu, last)
+			}
+			bounds = append(bounds, last)
+		}
+		base = append(base, WithBounds(bounds...))
+	}
+	return NewNeuralNet(trunk.Sensors, head.Actions, layers, append(base, options...)...)
+}
+
+// WithFrozenLayers freeze every neuron of the given layers, and unfreeze
+// the rest; frozen neurons are kept as they are by GetChild
+func WithFrozenLayers(layers ...int) Option {
+	return func(net *neuralnet) error {
+		frozen := net.emptyFrozen()
+		for _, i := range layers {
+			if i < 0 || i >= len(net.neurons) {
+				return fmt.Errorf("cannot freeze layer %v", i)
+			}
+			for j := range frozen[i] {
+				frozen[i][j] = true
+			}
+		}
+		net.frozen = frozen
+		return nil
+	}
+}
+
+// WithFrozenNeurons freeze the given neurons, and unfreeze the rest
+func WithFrozenNeurons(neurons ...NeuronRef) Option {
+	return func(net *neuralnet) error {
+		frozen := net.emptyFrozen()
+		for _, ref := range neurons {
+			if ref.Layer < 0 || ref.Layer >= len(net.neurons) || ref.Neuron < 0 || ref.Neuron >= len(net.neurons[ref.Layer]) {
+				return fmt.Errorf("cannot freeze neuron %v of layer %v", ref.Neuron, ref.Layer)
+			}
+			frozen[ref.Layer][ref.Neuron] = true
+		}
+		net.frozen = frozen
+		return nil
+	}
+}
+
+func withFrozen(frozen [][]bool) Option {
+	return func(net *neuralnet) error {
+		if frozen == nil {
+			net.frozen = nil
+			return nil
+		}
+		if len(frozen) != len(net.neurons) {
+			return fmt.Errorf("expected frozen flags for %v layers, got %v", len(net.neurons), len(frozen))
+		}
+		for i, flags := range frozen {
+			if len(flags) != len(net.neurons[i]) {
+				return fmt.Errorf("layer %v: expected %v frozen flags, got %v", i, len(net.neurons[i]), len(flags))
+			}
+		}
+		net.frozen = frozen
+		return nil
+	}
+}
+
+func (net neuralnet) IsFrozen(layer, index int) bool {
+	if layer < 0 || layer >= len(net.frozen) || index < 0 || index >= len(net.frozen[layer]) {
+		return false
+	}
+	return net.frozen[layer][index]
+}
+
+func (net neuralnet) emptyFrozen() [][]bool {
+	res := make([][]bool, len(net.neurons))
+	for i, layer := range net.neurons {
+		res[i] = make([]bool, len(layer))
+	}
+	return res
+}
+
+func (net neuralnet) anyFrozen() bool {
+	for _, flags := range net.frozen {
+		for _, flag := range flags {
+			if flag {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,245 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/cacilhas/neuron/neuron"
+)
+
+func TestArchive(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	var nets []neuron.NeuralNet
+	for i := 0; i < 5; i++ {
+		net, err := neuron.BuildNet(neuron.Topology{
+			Sensors: []string{"x", "y"},
+			Actions: []string{"go"},
+			Hidden:  []neuron.LayerSpec{{Width: i + 1}},
+			Rand:    rng,
+		}, neuron.WithMetadata(neuron.Metadata{neuron.MetaID: fmt.Sprintf("net-%v", i)}))
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		nets = append(nets, net)
+	}
+	write := func(t *testing.T, out io.Writer, nets []neuron.NeuralNet) {
+		writer, err := neuron.NewArchiveWriter(out)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		for i, net := range nets {
+			if err := writer.Add(net, "", float64(i)/10); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+	check := func(t *testing.T, arc neuron.Archive, nets []neuron.NeuralNet) {
+		entries := arc.GetEntries()
+		if len(entries) != len(nets) {
+			t.Fatalf("expected %v entries, got %v", len(nets), len(entries))
+		}
+		// Backwards, so no net is read in sequence
+		for i := len(nets) - 1; i >= 0; i-- {
+			if name := fmt.Sprintf("net-%v", i); entries[i].Name != name {
+				t.Fatalf("expected %v, got %v", name, entries[i].Name)
+			}
+			if fitness := float64(i) / 10; entries[i].Fitness != fitness {
+				t.Fatalf("expected %v, got %v", fitness, entries[i].Fitness)
+			}
+			net, err := arc.Load(i)
+			if err != nil {
+				t.Fatalf("entry %v: unexpected error %v", i, err)
+			}
+			if got := net.String(); got != nets[i].String() {
+				t.Fatalf("entry %v: expected\n%v\ngot\n%v", i, nets[i], got)
+			}
+		}
+	}
+
+	t.Run("OpenArchive", func(t *testing.T) {
+		var buf bytes.Buffer
+		write(t, &buf, nets)
+		arc, err := neuron.OpenArchive(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		check(t, arc, nets)
+		if index, ok := arc.Find("net-3"); !ok || index != 3 {
+			t.Fatalf("expected 3, got %v", index)
+		}
+		if _, ok := arc.Find("net-9"); ok {
+			t.Fatalf("net-9 expected not to be found")
+		}
+		if _, err := arc.Load(5); err == nil {
+			t.Fatalf("expected error not raised")
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		var buf bytes.Buffer
+		write(t, &buf, nil)
+		arc, err := neuron.OpenArchive(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		check(t, arc, nil)
+	})
+
+	t.Run("AppendArchive", func(t *testing.T) {
+		fp, err := ioutil.TempFile("", "archive")
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		defer os.Remove(fp.Name())
+		defer fp.Close()
+
+		write(t, fp, nets[:2])
+		for i := 2; i < len(nets); i++ {
+			writer, err := neuron.AppendArchive(fp)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if err := writer.Add(nets[i], "", float64(i)/10); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+		}
+		info, _ := fp.Stat()
+		arc, err := neuron.OpenArchive(fp, info.Size())
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		check(t, arc, nets)
+
+		// Streams read on past the former indexes
+		fp.Seek(0, io.SeekStart)
+		reader, err := neuron.NewArchiveReader(fp)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		for i, expected := range nets {
+			_, net, err := reader.Next()
+			if err != nil {
+				t.Fatalf("entry %v: unexpected error %v", i, err)
+			}
+			if got := net.String(); got != expected.String() {
+				t.Fatalf("entry %v: expected\n%v\ngot\n%v", i, expected, got)
+			}
+		}
+		if _, _, err := reader.Next(); err != io.EOF {
+			t.Fatalf("expected %v, got %v", io.EOF, err)
+		}
+	})
+
+	t.Run("AppendArchive without Close", func(t *testing.T) {
+		fp, err := ioutil.TempFile("", "archive")
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		defer os.Remove(fp.Name())
+		defer fp.Close()
+
+		write(t, fp, nets[:2])
+		writer, err := neuron.AppendArchive(fp)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if err := writer.Add(nets[2], "", 0.2); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		reopened, err := os.Open(fp.Name())
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		defer reopened.Close()
+		info, _ := reopened.Stat()
+		arc, err := neuron.OpenArchive(reopened, info.Size())
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		check(t, arc, nets[:2])
+
+		if err := writer.Close(); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		info, _ = reopened.Stat()
+		if arc, err = neuron.OpenArchive(reopened, info.Size()); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		check(t, arc, nets[:3])
+	})
+
+	t.Run("NewArchiveReader", func(t *testing.T) {
+		var buf bytes.Buffer
+		write(t, &buf, nets)
+		reader, err := neuron.NewArchiveReader(&buf)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		for i, expected := range nets {
+			entry, net, err := reader.Next()
+			if err != nil {
+				t.Fatalf("entry %v: unexpected error %v", i, err)
+			}
+			if name := fmt.Sprintf("net-%v", i); entry.Name != name {
+				t.Fatalf("expected %v, got %v", name, entry.Name)
+			}
+			if got := net.String(); got != expected.String() {
+				t.Fatalf("entry %v: expected\n%v\ngot\n%v", i, expected, got)
+			}
+		}
+		if _, _, err := reader.Next(); err != io.EOF {
+			t.Fatalf("expected %v, got %v", io.EOF, err)
+		}
+		if _, _, err := reader.Next(); err != io.EOF {
+			t.Fatalf("expected %v, got %v", io.EOF, err)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		var buf bytes.Buffer
+		write(t, &buf, nets)
+		data := buf.Bytes()
+
+		if _, err := neuron.OpenArchive(bytes.NewReader(data[1:]), int64(len(data)-1)); err != neuron.ErrUnknownFormat {
+			t.Fatalf("expected %v, got %v", neuron.ErrUnknownFormat, err)
+		}
+		if _, err := neuron.NewArchiveReader(bytes.NewReader(data[1:])); err != neuron.ErrUnknownFormat {
+			t.Fatalf("expected %v, got %v", neuron.ErrUnknownFormat, err)
+		}
+		for _, size := range []int{3, 20, len(data) / 2, len(data) - 1} {
+			if _, err := neuron.OpenArchive(bytes.NewReader(data[:size]), int64(size)); err == nil {
+				t.Fatalf("%v bytes: expected error not raised", size)
+			}
+		}
+
+		// An index pointing past itself
+		damaged := append([]byte{}, data...)
+		damaged[len(damaged)-5] ^= 0x10
+		if _, err := neuron.OpenArchive(bytes.NewReader(damaged), int64(len(damaged))); !errors.Is(err, neuron.ErrMalformed) {
+			t.Fatalf("expected %v, got %v", neuron.ErrMalformed, err)
+		}
+
+		// Streams cut before the index
+		reader, _ := neuron.NewArchiveReader(bytes.NewReader(data[:len(data)/2]))
+		var err error
+		for err == nil {
+			_, _, err = reader.Next()
+		}
+		if !errors.Is(err, neuron.ErrTruncated) {
+			t.Fatalf("expected %v, got %v", neuron.ErrTruncated, err)
+		}
+	})
+}
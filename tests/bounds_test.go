@@ -0,0 +1,187 @@
+package tests
+
+import (
+	"bytes"
+	"encoding"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/cacilhas/neuron/neuron"
+)
+
+func TestBounds(t *testing.T) {
+	t.Run("Apply", func(t *testing.T) {
+		clamp := neuron.Bounds{Min: -10, Max: 10, Mode: neuron.Clamp}
+		reflect := neuron.Bounds{Min: -10, Max: 10, Mode: neuron.Reflect}
+		cases := []struct {
+			bounds   neuron.Bounds
+			input    int
+			expected int
+		}{
+			{clamp, 5, 5},
+			{clamp, 12, 10},
+			{clamp, -15, -10},
+			{reflect, 5, 5},
+			{reflect, 12, 8},
+			{reflect, -15, -5},
+			{reflect, 35, -5},
+		}
+		for _, c := range cases {
+			if got := c.bounds.Apply(c.input); got != c.expected {
+				t.Fatalf("%v.Apply(%v): expected %v, got %v", c.bounds, c.input, c.expected, got)
+			}
+		}
+	})
+
+	t.Run("WithBounds", func(t *testing.T) {
+		layers := []neuron.Layer{{getGenes(t, 5, -5)}}
+		if _, err := neuron.NewNeuralNet([]string{"a", "b"}, []string{"x"}, layers, neuron.WithBounds(neuron.Bounds{Min: -5, Max: 5})); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		_, err := neuron.NewNeuralNet([]string{"a", "b"}, []string{"x"}, layers, neuron.WithBounds(neuron.Bounds{Min: -4, Max: 4}))
+		rangeErr, ok := err.(*neuron.GeneRangeError)
+		if !ok {
+			t.Fatalf("expected gene range error, got %v", err)
+		}
+		if rangeErr.Gene != 0 || rangeErr.Value != 5 {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if _, err := neuron.NewNeuralNet([]string{"a", "b"}, []string{"x"}, layers, neuron.WithBounds(neuron.Bounds{}, neuron.Bounds{})); err == nil {
+			t.Fatalf("expected error not raised")
+		}
+	})
+
+	t.Run("GetChild", func(t *testing.T) {
+		bounds := neuron.Bounds{Min: -100, Max: 100, Mode: neuron.Reflect}
+		net, err := neuron.BuildNet(
+			neuron.NewTopology([]string{"a", "b"}, []string{"x"}, 4),
+			neuron.WithBounds(bounds),
+		)
+		if err == nil {
+			t.Fatalf("expected error not raised")
+		}
+
+		topology := neuron.NewTopology([]string{"a", "b"}, []string{"x"}, 4)
+		topology.Hidden[0].Initialiser = neuron.Uniform(-100, 100)
+		topology.Output.Initialiser = neuron.Uniform(-100, 100)
+		net, err = neuron.BuildNet(topology, neuron.WithBounds(bounds))
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		rand.Seed(0)
+		for i := 0; i < 10; i++ {
+			net = net.GetChild(1000)
+		}
+		for i := 0; i < 2; i++ {
+			if got := net.GetBounds(i); got != bounds {
+				t.Fatalf("expected %v, got %v", bounds, got)
+			}
+			for _, neu := range net.GetNeurons(i) {
+				for j := 0; j < neu.GetSize(); j++ {
+					if !bounds.Contains(neu.GetGene(j)) {
+						t.Fatalf("gene %v out of bounds", neu.GetGene(j))
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("Child saturates", func(t *testing.T) {
+		neu, _ := neuron.NewNeuron([]int{neuron.MaxGene, neuron.MinGene})
+		rand.Seed(0)
+		for i := 0; i < 10; i++ {
+			neu = neu.Child(1000)
+		}
+		if got := neu.GetGene(0); got > neuron.MaxGene {
+			t.Fatalf("gene %v overflows", got)
+		}
+		if got := neu.GetGene(1); got < neuron.MinGene {
+			t.Fatalf("gene %v overflows", got)
+		}
+	})
+
+	t.Run("Save", func(t *testing.T) {
+		layers := []neuron.Layer{{getGenes(t, neuron.MaxGene+1, 0)}}
+		net, err := neuron.NewNeuralNet([]string{"a", "b"}, []string{"x"}, layers)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		var buf bytes.Buffer
+		if _, ok := net.Save(&buf).(*neuron.GeneRangeError); !ok {
+			t.Fatalf("expected gene range error")
+		}
+
+		wide, err := neuron.NewNeuralNet([]string{"a", "b"}, []string{"x"}, []neuron.Layer{{getGenes(t, 0, 1<<33)}})
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		_, marshalErr := wide.MarshalBinary()
+		_, writeErr := wide.WriteTo(&buf)
+		for _, err := range []error{wide.SaveFormat(&buf, neuron.Legacy), wide.SaveFormat(&buf, neuron.V2), marshalErr, writeErr} {
+			if got, ok := err.(*neuron.GeneRangeError); !ok || got.Gene != 1 || got.Value != 1<<33 {
+				t.Fatalf("expected gene range error for gene 1, got %v", err)
+			}
+		}
+		if buf.Len() > 0 {
+			t.Fatalf("expected nothing written, got %v bytes", buf.Len())
+		}
+	})
+
+	t.Run("Neuron encoding", func(t *testing.T) {
+		neu := getGenes(t, 0, 1<<33)
+		isRangeError := func(err interface{}) {
+			if got, ok := err.(*neuron.GeneRangeError); !ok || got.Layer != -1 || got.Gene != 1 || got.Value != 1<<33 {
+				t.Fatalf("expected gene range error for gene 1, got %v", err)
+			}
+		}
+		_, err := neu.(encoding.BinaryMarshaler).MarshalBinary()
+		isRangeError(err)
+		var buf bytes.Buffer
+		written, err := neu.(io.WriterTo).WriteTo(&buf)
+		isRangeError(err)
+		if written != 0 || buf.Len() > 0 {
+			t.Fatalf("expected nothing written, got %v bytes", buf.Len())
+		}
+		for name, encode := range map[string]func(){
+			"String":  func() { _ = neu.String() },
+			"Marshal": func() { neu.Marshal() },
+		} {
+			func() {
+				defer func() { isRangeError(recover()) }()
+				encode()
+				t.Fatalf("%v expected to panic", name)
+			}()
+		}
+	})
+
+	t.Run("LoadNet", func(t *testing.T) {
+		bounds := []neuron.Bounds{{Min: -10, Max: 10, Mode: neuron.Reflect}, {Min: -20, Max: 20}}
+		layers := []neuron.Layer{{getGenes(t, 5, -5)}, {getGenes(t, 15)}}
+		net, err := neuron.NewNeuralNet([]string{"a", "b"}, []string{"x"}, layers, neuron.WithBounds(bounds...))
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		var buf bytes.Buffer
+		if err := net.Save(&buf); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		loaded, err := neuron.LoadNet(&buf)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		for i, expected := range bounds {
+			if got := loaded.GetBounds(i); got != expected {
+				t.Fatalf("expected %v, got %v", expected, got)
+			}
+		}
+	})
+}
+
+func getGenes(t *testing.T, genes ...int) neuron.Neuron {
+	neu, err := neuron.NewNeuron(genes)
+	if err != nil {
+		t.Fatalf("error intantiating neuron: %v", err)
+	}
+	return neu
+}
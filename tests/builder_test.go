@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/cacilhas/neuron/neuron"
+)
+
+func TestBuilder(t *testing.T) {
+	sensors := []string{"distance", "height"}
+	actions := []string{"jump"}
+
+	t.Run("BuildNet", func(t *testing.T) {
+		net, err := neuron.BuildNet(neuron.NewTopology(sensors, actions, 3, 2))
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		for i, expected := range [][2]int{{3, 2}, {2, 3}, {1, 2}} {
+			neurons := net.GetNeurons(i)
+			if got := len(neurons); got != expected[0] {
+				t.Fatalf("layer %v: expected %v neurons, got %v", i, expected[0], got)
+			}
+			for _, neu := range neurons {
+				if got := neu.GetSize(); got != expected[1] {
+					t.Fatalf("layer %v: expected size %v, got %v", i, expected[1], got)
+				}
+			}
+		}
+		if _, err := net.Compute(map[string]float64{"distance": -12, "height": 246.128}); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	})
+
+	t.Run("ParseTopology", func(t *testing.T) {
+		topology, err := neuron.ParseTopology("2-3-2-1", sensors, actions)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if got := len(topology.Hidden); got != 2 {
+			t.Fatalf("expected 2 hidden layers, got %v", got)
+		}
+		if got := topology.Hidden[0].Width; got != 3 {
+			t.Fatalf("expected 3, got %v", got)
+		}
+
+		for _, spec := range []string{"3-2-1", "2-2-2", "2-x-1", "2"} {
+			if _, err := neuron.ParseTopology(spec, sensors, actions); err == nil {
+				t.Fatalf("%v: expected error not raised", spec)
+			}
+		}
+	})
+
+	t.Run("invalid width", func(t *testing.T) {
+		if _, err := neuron.BuildNet(neuron.NewTopology(sensors, actions, 0)); err == nil {
+			t.Fatalf("expected error not raised")
+		}
+	})
+
+	t.Run("Initialiser", func(t *testing.T) {
+		topology := neuron.NewTopology(sensors, actions, 2)
+		topology.Hidden[0].Initialiser = func(_ *rand.Rand, width, size int) [][]int {
+			return [][]int{{1, 0}, {0, 1}}
+		}
+		topology.Output.Initialiser = func(_ *rand.Rand, width, size int) [][]int {
+			return [][]int{{-1, 1}}
+		}
+		net, err := neuron.BuildNet(topology)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		got, _ := net.Compute(map[string]float64{"distance": 1, "height": 2})
+		if !got["jump"] {
+			t.Fatalf("jump expected to be trigged")
+		}
+		got, _ = net.Compute(map[string]float64{"distance": 2, "height": 1})
+		if got["jump"] {
+			t.Fatalf("jump expected not to be trigged")
+		}
+	})
+
+	t.Run("Activation", func(t *testing.T) {
+		topology := neuron.NewTopology(sensors, actions)
+		topology.Output = neuron.LayerSpec{
+			Initialiser: func(_ *rand.Rand, width, size int) [][]int {
+				return [][]int{{1, 0}}
+			},
+			Activation: neuron.Sigmoid,
+		}
+		net, err := neuron.BuildNet(topology)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if got := net.GetActivation(0); got != neuron.Sigmoid {
+			t.Fatalf("expected sigmoid, got %v", got)
+		}
+		got, _ := net.Compute(map[string]float64{"distance": 1, "height": 0})
+		if !got["jump"] {
+			t.Fatalf("jump expected to be trigged")
+		}
+		got, _ = net.Compute(map[string]float64{"distance": -1, "height": 0})
+		if got["jump"] {
+			t.Fatalf("jump expected not to be trigged")
+		}
+		if !strings.Contains(net.String(), "ACTIVATIONS: sigmoid\n") {
+			t.Fatalf("expected activations in %v", net.String())
+		}
+
+		var buf bytes.Buffer
+		if err := net.Save(&buf); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		loaded, err := neuron.LoadNet(&buf)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if got := loaded.GetActivation(0); got != neuron.Sigmoid {
+			t.Fatalf("expected sigmoid, got %v", got)
+		}
+	})
+}
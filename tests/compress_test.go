@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+	"testing/iotest"
+
+	"github.com/cacilhas/neuron/neuron"
+)
+
+func TestCompressed(t *testing.T) {
+	net, err := neuron.BuildNet(neuron.Topology{
+		Sensors: []string{"x", "y", "z"},
+		Actions: []string{"left", "right"},
+		Hidden:  []neuron.LayerSpec{{Width: 40}, {Width: 40}},
+		Rand:    rand.New(rand.NewSource(0)),
+	}, neuron.WithFrozenLayers(0))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	compressed := func(t *testing.T) []byte {
+		var buf bytes.Buffer
+		if err := net.SaveCompressed(&buf, gzip.BestCompression); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		return buf.Bytes()
+	}
+
+	t.Run("SaveCompressed", func(t *testing.T) {
+		var plain bytes.Buffer
+		net.Save(&plain)
+		data := compressed(t)
+		if len(data) >= plain.Len() {
+			t.Fatalf("expected less than %v bytes, got %v", plain.Len(), len(data))
+		}
+		if err := net.SaveCompressed(&plain, 42); err == nil {
+			t.Fatalf("expected error not raised")
+		}
+	})
+
+	t.Run("LoadNet", func(t *testing.T) {
+		for _, format := range []neuron.Format{neuron.Legacy, neuron.V2} {
+			var buf bytes.Buffer
+			writer := gzip.NewWriter(&buf)
+			net.SaveFormat(writer, format)
+			writer.Close()
+			loaded, err := neuron.LoadNet(&buf)
+			if err != nil {
+				t.Fatalf("format %v: unexpected error %v", format, err)
+			}
+			if got := loaded.String(); got != net.String() {
+				t.Fatalf("format %v: expected\n%v\ngot\n%v", format, net, got)
+			}
+		}
+	})
+
+	t.Run("trailing data", func(t *testing.T) {
+		data := append(compressed(t), 0xca, 0xfe)
+		for name, input := range map[string]io.Reader{
+			"byte reader":  bytes.NewReader(data),
+			"plain reader": iotest.HalfReader(bytes.NewReader(data)),
+		} {
+			if _, err := neuron.LoadNet(input); err != nil {
+				t.Fatalf("%v: unexpected error %v", name, err)
+			}
+			rest, _ := ioutil.ReadAll(input)
+			if !bytes.Equal(rest, []byte{0xca, 0xfe}) {
+				t.Fatalf("%v: expected trailing data left unread, got %v", name, rest)
+			}
+		}
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		// Compressed and uncompressed nets read one after another
+		var buf bytes.Buffer
+		net.SaveCompressed(&buf, gzip.BestSpeed)
+		net.Save(&buf)
+		net.SaveCompressed(&buf, gzip.DefaultCompression)
+		for i := 0; i < 3; i++ {
+			if _, err := neuron.LoadNet(&buf); err != nil {
+				t.Fatalf("net %v: unexpected error %v", i, err)
+			}
+		}
+		if _, err := neuron.LoadNet(&buf); err != io.EOF {
+			t.Fatalf("expected %v, got %v", io.EOF, err)
+		}
+	})
+
+	t.Run("checksum", func(t *testing.T) {
+		data := compressed(t)
+		data[len(data)-5] ^= 0x01
+		if _, err := neuron.LoadNet(bytes.NewReader(data)); err != neuron.ErrChecksum {
+			t.Fatalf("expected %v, got %v", neuron.ErrChecksum, err)
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		data := compressed(t)
+		for _, size := range []int{3, 5, 12, len(data) / 2, len(data) - 1} {
+			_, err := neuron.LoadNet(bytes.NewReader(data[:size]))
+			if !errors.Is(err, neuron.ErrTruncated) {
+				t.Fatalf("%v bytes: expected %v, got %v", size, neuron.ErrTruncated, err)
+			}
+		}
+	})
+
+	t.Run("garbage", func(t *testing.T) {
+		var buf bytes.Buffer
+		writer := gzip.NewWriter(&buf)
+		writer.Write([]byte("this is no neural network"))
+		writer.Close()
+		if _, err := neuron.LoadNet(&buf); err != neuron.ErrUnknownFormat {
+			t.Fatalf("expected %v, got %v", neuron.ErrUnknownFormat, err)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/cacilhas/neuron/neuron"
+)
+
+func TestEvaluateCSV(t *testing.T) {
+	layers := []neuron.Layer{{getGenes(t, 1, -1), getGenes(t, -1, 1)}}
+	net, err := neuron.NewNeuralNet([]string{"x", "y"}, []string{"left", "right"}, layers, neuron.WithActivations(neuron.Linear))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	evaluate := func(t *testing.T, net neuron.NeuralNet, input string, options neuron.EvaluateOptions) (string, int, error) {
+		var buf bytes.Buffer
+		count, err := neuron.EvaluateCSV(strings.NewReader(input), &buf, net, options)
+		return buf.String(), count, err
+	}
+
+	t.Run("booleans", func(t *testing.T) {
+		got, count, err := evaluate(t, net, "id,y,x\na,1,2\nb,3,1\n", neuron.EvaluateOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if count != 2 {
+			t.Fatalf("expected 2 rows, got %v", count)
+		}
+		if expected := "left,right\ntrue,false\nfalse,true\n"; got != expected {
+			t.Fatalf("expected\n%v\ngot\n%v", expected, got)
+		}
+	})
+
+	t.Run("Raw and Keep", func(t *testing.T) {
+		got, _, err := evaluate(t, net, "id;x;y\na;2;0.5\n", neuron.EvaluateOptions{Raw: true, Keep: true, Comma: ';'})
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if expected := "id;x;y;left;right\na;2;0.5;1.5;-1.5\n"; got != expected {
+			t.Fatalf("expected\n%v\ngot\n%v", expected, got)
+		}
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		zero := 0.0
+		defaulted, err := neuron.Configure(net, neuron.WithSchema(neuron.Schema{"y": {Default: &zero}}))
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		got, _, err := evaluate(t, defaulted, "x\n1\n", neuron.EvaluateOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		expected := "left,right\ntrue,false\n"
+		if got != expected {
+			t.Fatalf("expected\n%v\ngot\n%v", expected, got)
+		}
+		if got, _, err = evaluate(t, defaulted, "x,y\n1,\n", neuron.EvaluateOptions{}); err != nil || got != expected {
+			t.Fatalf("expected empty cell to read the default, got %v, %v", got, err)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for input, message := range map[string]string{
+			"":                "missing header",
+			"x\n1\n":          "no column for sensor y",
+			"x,y,x\n1,2,3\n":  "column x repeated",
+			"x,y\n1,a\n":      `row 2, column y: invalid reading "a"`,
+			"x,y\n1,2\n3,\n":  "row 3: missing reading for sensor y",
+			"x,y\n1,2\n3,4,5": "wrong number of fields",
+		} {
+			_, _, err := evaluate(t, net, input, neuron.EvaluateOptions{})
+			if err == nil || !strings.Contains(err.Error(), message) {
+				t.Fatalf("expected %q for %q, got %v", message, input, err)
+			}
+		}
+		got, count, _ := evaluate(t, net, "x,y\n1,2\n3,\n", neuron.EvaluateOptions{})
+		if count != 1 {
+			t.Fatalf("expected 1 row evaluated before the error, got %v", count)
+		}
+		if expected := "left,right\nfalse,true\n"; got != expected {
+			t.Fatalf("expected rows before the error written\n%v\ngot\n%v", expected, got)
+		}
+	})
+}
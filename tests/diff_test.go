@@ -0,0 +1,194 @@
+package tests
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cacilhas/neuron/neuron"
+)
+
+func TestDiff(t *testing.T) {
+	layers := []neuron.Layer{
+		{getGenes(t, 100, -200), getGenes(t, 300, 400)},
+		{getGenes(t, 500, 600), getGenes(t, -700, 800)},
+	}
+	parent, err := neuron.NewNeuralNet([]string{"x", "y"}, []string{"left", "right"}, layers)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	t.Run("same", func(t *testing.T) {
+		diff := neuron.Diff(parent, parent)
+		if !diff.Same() {
+			t.Fatalf("expected no difference, got\n%v", diff)
+		}
+		if diff.Stats.Compared != 8 {
+			t.Fatalf("expected 8 genes compared, got %v", diff.Stats.Compared)
+		}
+		if got := diff.String(); got != "genes: 0 of 8 changed\n" {
+			t.Fatalf("unexpected report\n%v", got)
+		}
+	})
+
+	t.Run("genes", func(t *testing.T) {
+		child, _ := neuron.NewNeuralNet([]string{"x", "y"}, []string{"left", "right"}, []neuron.Layer{
+			{getGenes(t, 100, -200), getGenes(t, 310, 400)},
+			{getGenes(t, 500, 600), getGenes(t, -700, 770)},
+		})
+		diff := neuron.Diff(parent, child)
+		expected := []neuron.GeneDelta{
+			{Layer: 0, Neuron: 1, Gene: 0, Before: 300, After: 310},
+			{Layer: 1, Neuron: 1, Gene: 1, Before: 800, After: 770},
+		}
+		if !reflect.DeepEqual(diff.Genes, expected) {
+			t.Fatalf("expected %v, got %v", expected, diff.Genes)
+		}
+		stats := neuron.GeneStats{Compared: 8, Changed: 2, Mean: -2.5, MeanAbs: 5, RMS: 11.180339887498949, MaxAbs: 30}
+		if diff.Stats != stats {
+			t.Fatalf("expected %+v, got %+v", stats, diff.Stats)
+		}
+		if !strings.Contains(diff.String(), "  1.1[1]: 800 -> 770 (-30)\n") {
+			t.Fatalf("unexpected report\n%v", diff)
+		}
+	})
+
+	t.Run("sensors and actions", func(t *testing.T) {
+		grown, err := neuron.AddSensor(parent, "a")
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		grown, err = neuron.RemoveAction(grown, "left")
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		diff := neuron.Diff(parent, grown)
+		if !reflect.DeepEqual(diff.AddedSensors, []string{"a"}) || diff.RemovedSensors != nil {
+			t.Fatalf("expected sensor a added, got %v, %v", diff.AddedSensors, diff.RemovedSensors)
+		}
+		if diff.AddedActions != nil || !reflect.DeepEqual(diff.RemovedActions, []string{"left"}) {
+			t.Fatalf("expected action left removed, got %v, %v", diff.AddedActions, diff.RemovedActions)
+		}
+		if !diff.Comparable || diff.Genes != nil {
+			t.Fatalf("expected matched genes unchanged, got %v", diff.Genes)
+		}
+		// Genes of x and y in the first layer, of right in the last one
+		if diff.Stats.Compared != 6 {
+			t.Fatalf("expected 6 genes compared, got %v", diff.Stats.Compared)
+		}
+		for _, line := range []string{"sensors added: a\n", "actions removed: left\n", "layers: 2, 2 -> 2, 1\n"} {
+			if !strings.Contains(diff.String(), line) {
+				t.Fatalf("expected %q in\n%v", line, diff)
+			}
+		}
+	})
+
+	t.Run("settings", func(t *testing.T) {
+		tanh, err := neuron.Configure(parent, neuron.WithActivations(neuron.Tanh, parent.GetActivation(1)))
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		diff := neuron.Diff(parent, tanh)
+		if diff.Same() {
+			t.Fatalf("nets differing in activation expected not to be the same")
+		}
+		if len(diff.Genes) != 0 {
+			t.Fatalf("expected no gene changed, got %v", diff.Genes)
+		}
+		expected := []neuron.SettingDelta{{Setting: "activation", Layer: 0, Neuron: -1, Before: "relu", After: "tanh"}}
+		if !reflect.DeepEqual(diff.Settings, expected) {
+			t.Fatalf("expected %v, got %v", expected, diff.Settings)
+		}
+		if !strings.Contains(diff.String(), "activation 0: relu -> tanh\n") {
+			t.Fatalf("unexpected report\n%v", diff)
+		}
+
+		configured, err := neuron.Configure(parent,
+			neuron.WithBounds(neuron.Bounds{Min: -1000, Max: 1000}),
+			neuron.WithNormalisers(map[string]neuron.Normaliser{"x": {Method: neuron.MinMax, Min: 0, Max: 10}}),
+			neuron.WithFrozenNeurons(neuron.NeuronRef{Layer: 1, Neuron: 0}))
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		diff = neuron.Diff(parent, configured)
+		if diff.Same() {
+			t.Fatalf("nets differing in settings expected not to be the same")
+		}
+		var settings []string
+		for _, delta := range diff.Settings {
+			settings = append(settings, delta.Setting)
+		}
+		if got := strings.Join(settings, ", "); got != "bounds, bounds, normaliser, frozen" {
+			t.Fatalf("expected bounds, bounds, normaliser, frozen, got %v", got)
+		}
+		if !neuron.Diff(configured, configured).Same() {
+			t.Fatalf("expected no difference")
+		}
+	})
+
+	t.Run("shapes", func(t *testing.T) {
+		deeper, _ := neuron.BuildNet(neuron.NewTopology([]string{"x", "y"}, []string{"left", "right"}, 3))
+		diff := neuron.Diff(parent, deeper)
+		if diff.Comparable || diff.Same() {
+			t.Fatalf("expected incomparable genes")
+		}
+		if !reflect.DeepEqual(diff.WidthsBefore, []int{2, 2}) || !reflect.DeepEqual(diff.WidthsAfter, []int{3, 2}) {
+			t.Fatalf("unexpected widths %v, %v", diff.WidthsBefore, diff.WidthsAfter)
+		}
+		if got := diff.String(); got != "layers: 2, 2 -> 3, 2\ngenes: not comparable\n" {
+			t.Fatalf("unexpected report\n%v", got)
+		}
+	})
+
+	t.Run("DiffBehaviour", func(t *testing.T) {
+		flipped, _ := neuron.NewNeuralNet([]string{"x", "y"}, []string{"left", "right"}, []neuron.Layer{
+			layers[0],
+			{getGenes(t, 500, 600), getGenes(t, 700, 800)},
+		})
+		samples := []map[string]float64{{"x": 1, "y": 0}, {"x": 0, "y": 1}, {"x": -1, "y": -1}}
+		diff, err := neuron.DiffBehaviour(parent, flipped, neuron.BehaviourOptions{Samples: samples})
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if diff.Samples != 3 {
+			t.Fatalf("expected 3 samples, got %v", diff.Samples)
+		}
+		if got := diff.Actions["left"]; got != (neuron.ActionDiff{}) {
+			t.Fatalf("left expected unchanged, got %+v", got)
+		}
+		// right differs where the first hidden neuron fires
+		right := diff.Actions["right"]
+		if right.Differ != 1 || right.MaxDelta != 140000 {
+			t.Fatalf("unexpected right %+v", right)
+		}
+
+		diff, err = neuron.DiffBehaviour(parent, parent, neuron.BehaviourOptions{Count: 50, Rand: rand.New(rand.NewSource(0))})
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if diff.Samples != 50 || diff.Actions["left"].Differ != 0 || diff.Actions["right"].Differ != 0 {
+			t.Fatalf("unexpected diff\n%v", diff)
+		}
+		if !strings.HasPrefix(diff.String(), "samples: 50\nleft: differs 0 times (0.0%)") {
+			t.Fatalf("unexpected report\n%v", diff)
+		}
+
+		if _, err := neuron.DiffBehaviour(parent, flipped, neuron.BehaviourOptions{Samples: []map[string]float64{{"x": 1}}}); err == nil {
+			t.Fatalf("expected error not raised")
+		}
+	})
+
+	t.Run("SampleInputs", func(t *testing.T) {
+		documented, err := neuron.Configure(parent, neuron.WithSchema(neuron.Schema{"x": {Min: 10, Max: 20}}))
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		samples := neuron.SampleInputs(100, rand.New(rand.NewSource(0)), documented)
+		for _, sample := range samples {
+			if sample["x"] < 10 || sample["x"] > 20 || sample["y"] < -1 || sample["y"] > 1 {
+				t.Fatalf("sample %v out of range", sample)
+			}
+		}
+	})
+}
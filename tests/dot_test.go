@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/cacilhas/neuron/neuron"
+)
+
+func TestWriteDot(t *testing.T) {
+	layers := []neuron.Layer{
+		{getGenes(t, 300, -1000), getGenes(t, 0, 5)},
+		{getGenes(t, 700, -20)},
+	}
+	net, err := neuron.NewNeuralNet([]string{"x", `say "hi"`}, []string{"go"}, layers, neuron.WithFrozenNeurons(neuron.NeuronRef{Layer: 0, Neuron: 1}))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	draw := func(t *testing.T, options neuron.DotOptions) string {
+		var buf bytes.Buffer
+		if err := neuron.WriteDot(&buf, net, options); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		return buf.String()
+	}
+
+	t.Run("nodes", func(t *testing.T) {
+		got := draw(t, neuron.DotOptions{})
+		for _, line := range []string{
+			`digraph "net" {`,
+			`s0 [label="say \"hi\"", shape=box, fixedsize=false];`,
+			`s1 [label="x", shape=box, fixedsize=false];`,
+			`n0_0 [label="0.0"];`,
+			`n0_1 [label="0.1", style=filled, fillcolor=lightgrey];`,
+			`n1_0 [label="go", shape=doublecircle, fixedsize=false];`,
+		} {
+			if !strings.Contains(got, line) {
+				t.Fatalf("expected %q in\n%v", line, got)
+			}
+		}
+		if !strings.HasSuffix(got, "}\n") {
+			t.Fatalf("expected a closed graph, got\n%v", got)
+		}
+	})
+
+	t.Run("edges", func(t *testing.T) {
+		got := draw(t, neuron.DotOptions{Name: "walker"})
+		for _, line := range []string{
+			`digraph "walker" {`,
+			`s0 -> n0_0 [color="#1f77b479", penwidth=1.85, tooltip="300"];`,
+			`s1 -> n0_0 [color="#d62728ff", penwidth=5.00, tooltip="-1000"];`,
+			`s1 -> n0_1 [color="#1f77b440", penwidth=0.52, tooltip="5"];`,
+			`n0_0 -> n1_0 [color="#1f77b4c5", penwidth=3.65, tooltip="700"];`,
+			`n0_1 -> n1_0 [color="#d6272843", penwidth=0.59, tooltip="-20"];`,
+		} {
+			if !strings.Contains(got, line) {
+				t.Fatalf("expected %q in\n%v", line, got)
+			}
+		}
+		if strings.Contains(got, "s0 -> n0_1") {
+			t.Fatalf("zero gene expected to be hidden in\n%v", got)
+		}
+	})
+
+	t.Run("threshold", func(t *testing.T) {
+		got := draw(t, neuron.DotOptions{Threshold: 20})
+		if count := strings.Count(got, " -> "); count != 3 {
+			t.Fatalf("expected 3 edges, got %v in\n%v", count, got)
+		}
+		if strings.Contains(got, `tooltip="-20"`) {
+			t.Fatalf("gene -20 expected to be hidden in\n%v", got)
+		}
+	})
+}
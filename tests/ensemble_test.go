@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/cacilhas/neuron/neuron"
+)
+
+func TestEnsemble(t *testing.T) {
+	build := func(genes ...int) neuron.NeuralNet {
+		net, err := neuron.NewNeuralNet([]string{"a"}, []string{"x"}, []neuron.Layer{{getGenes(t, genes...)}})
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		return net
+	}
+	// Positive readings: the first two members fire, the last does not
+	members := []neuron.NeuralNet{build(3), build(1), build(-6)}
+	input := map[string]float64{"a": 1}
+
+	cases := []struct {
+		voting   neuron.Voting
+		weights  []float64
+		expected bool
+	}{
+		{neuron.Majority, nil, true},
+		{neuron.Unanimous, nil, false},
+		{neuron.Weighted, []float64{1, 1, 3}, false},
+		{neuron.Weighted, []float64{3, 1, 3}, true},
+		{neuron.Average, nil, true},
+		{neuron.Average, []float64{0, 0, 1}, false},
+	}
+	for _, c := range cases {
+		ens, err := neuron.NewEnsemble(c.voting, members, c.weights)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		got, err := ens.Compute(input)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if got["x"] != c.expected {
+			t.Fatalf("%v %v: expected %v, got %v", c.voting, c.weights, c.expected, got["x"])
+		}
+	}
+
+	t.Run("Activate", func(t *testing.T) {
+		ens, _ := neuron.NewEnsemble(neuron.Average, members, []float64{1, 1, 2})
+		got, err := ens.Activate(map[string]float64{"a": 2})
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if got["x"] != 2 {
+			t.Fatalf("expected 2, got %v", got["x"])
+		}
+	})
+
+	t.Run("NewEnsemble", func(t *testing.T) {
+		other, _ := neuron.NewNeuralNet([]string{"b"}, []string{"x"}, []neuron.Layer{{getGenes(t, 1)}})
+		if _, err := neuron.NewEnsemble(neuron.Majority, []neuron.NeuralNet{members[0], other}, nil); err == nil {
+			t.Fatalf("expected error not raised")
+		}
+		if _, err := neuron.NewEnsemble(neuron.Majority, members, []float64{1}); err == nil {
+			t.Fatalf("expected error not raised")
+		}
+		if _, err := neuron.NewEnsemble(neuron.Majority, nil, nil); err == nil {
+			t.Fatalf("expected error not raised")
+		}
+	})
+
+	t.Run("LoadEnsemble", func(t *testing.T) {
+		ens, _ := neuron.NewEnsemble(neuron.Weighted, members, []float64{3, 1, 3})
+		var buf bytes.Buffer
+		if err := ens.Save(&buf); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		loaded, err := neuron.LoadEnsemble(&buf)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if got := loaded.String(); got != ens.String() {
+			t.Fatalf("expected\n%v\ngot\n%v", ens, got)
+		}
+		if got := loaded.GetVoting(); got != neuron.Weighted {
+			t.Fatalf("expected weighted, got %v", got)
+		}
+		if got, _ := loaded.Compute(input); !got["x"] {
+			t.Fatalf("x expected to be trigged")
+		}
+		if _, err := neuron.LoadEnsemble(bytes.NewReader([]byte("nope, not an ensemble"))); err != neuron.ErrUnknownFormat {
+			t.Fatalf("expected ErrUnknownFormat, got %v", err)
+		}
+		if err := ens.Save(&buf); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		saved := buf.Bytes()
+		for _, size := range []int{6, 20, len(saved) - 1} {
+			_, err := neuron.LoadEnsemble(bytes.NewReader(saved[:size]))
+			var decodeErr *neuron.DecodeError
+			if !errors.Is(err, neuron.ErrTruncated) || !errors.As(err, &decodeErr) {
+				t.Fatalf("expected a truncation error for %v bytes, got %v", size, err)
+			}
+		}
+	})
+
+	t.Run("GetChild", func(t *testing.T) {
+		ens, _ := neuron.NewEnsemble(neuron.Majority, members, nil)
+		child := ens.GetChild(10)
+		if got := len(child.GetMembers()); got != 3 {
+			t.Fatalf("expected 3 members, got %v", got)
+		}
+	})
+}
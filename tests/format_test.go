@@ -0,0 +1,385 @@
+package tests
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math/rand"
+	"testing"
+	"testing/iotest"
+
+	"github.com/cacilhas/neuron/neuron"
+)
+
+func TestFormat(t *testing.T) {
+	net, err := neuron.BuildNet(neuron.Topology{
+		Sensors: []string{"x", "y"},
+		Actions: []string{"left", "right"},
+		Hidden:  []neuron.LayerSpec{{Width: 3, Activation: neuron.Tanh}},
+		Rand:    rand.New(rand.NewSource(0)),
+	}, neuron.WithBounds(neuron.Bounds{Min: -2000, Max: 2000}), neuron.WithFrozenLayers(0))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	save := func(t *testing.T, format neuron.Format) []byte {
+		var buf bytes.Buffer
+		if err := net.SaveFormat(&buf, format); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		return buf.Bytes()
+	}
+	// resave wrap a V2 payload edited by change into a file again
+	resave := func(change func([]byte) []byte) []byte {
+		data := save(t, neuron.V2)
+		size := binary.BigEndian.Uint32(data[6:])
+		payload := change(append([]byte{}, data[10:10+size]...))
+
+		var buf bytes.Buffer
+		var current [4]byte
+		buf.Write(data[:6])
+		binary.BigEndian.PutUint32(current[:], uint32(len(payload)))
+		buf.Write(current[:])
+		buf.Write(payload)
+		binary.BigEndian.PutUint32(current[:], crc32.ChecksumIEEE(payload))
+		buf.Write(current[:])
+		return buf.Bytes()
+	}
+	isMalformed := func(t *testing.T, err error) {
+		if _, ok := err.(*neuron.DecodeError); !ok || !errors.Is(err, neuron.ErrMalformed) {
+			t.Fatalf("expected a *neuron.DecodeError wrapping %v, got %T (%v)", neuron.ErrMalformed, err, err)
+		}
+	}
+
+	t.Run("Save", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := net.Save(&buf); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if !bytes.Equal(buf.Bytes(), save(t, neuron.CurrentFormat)) {
+			t.Fatalf("Save expected to write the current format")
+		}
+		expected := []byte{0x89, 'N', 'R', 'N', 0, 2}
+		if got := buf.Bytes()[:6]; !bytes.Equal(got, expected) {
+			t.Fatalf("expected %v, got %v", expected, got)
+		}
+		if err := net.SaveFormat(&buf, neuron.Format(3)); err == nil {
+			t.Fatalf("expected error not raised")
+		}
+	})
+
+	t.Run("LoadNet", func(t *testing.T) {
+		for _, format := range []neuron.Format{neuron.Legacy, neuron.V2} {
+			data := save(t, format)
+			// Trailing data is left unread
+			input := bytes.NewBuffer(append(append([]byte{}, data...), 0xff))
+			loaded, err := neuron.LoadNet(input)
+			if err != nil {
+				t.Fatalf("format %v: unexpected error %v", format, err)
+			}
+			if got := loaded.String(); got != net.String() {
+				t.Fatalf("format %v: expected\n%v\ngot\n%v", format, net, got)
+			}
+			if input.Len() != 1 {
+				t.Fatalf("format %v: expected 1 byte left, got %v", format, input.Len())
+			}
+		}
+	})
+
+	t.Run("checksum", func(t *testing.T) {
+		data := save(t, neuron.V2)
+		data[20] ^= 0x01
+		if _, err := neuron.LoadNet(bytes.NewReader(data)); err != neuron.ErrChecksum {
+			t.Fatalf("expected %v, got %v", neuron.ErrChecksum, err)
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		data := []byte("this is no neural network")
+		if _, err := neuron.LoadNet(bytes.NewReader(data)); err != neuron.ErrUnknownFormat {
+			t.Fatalf("expected %v, got %v", neuron.ErrUnknownFormat, err)
+		}
+	})
+
+	t.Run("version", func(t *testing.T) {
+		data := save(t, neuron.V2)
+		data[5] = 9
+		_, err := neuron.LoadNet(bytes.NewReader(data))
+		isMalformed(t, err)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		data := save(t, neuron.V2)
+		for _, size := range []int{3, 5, 9, len(data) / 2, len(data) - 1} {
+			if _, err := neuron.LoadNet(bytes.NewReader(data[:size])); err == nil {
+				t.Fatalf("%v bytes: expected error not raised", size)
+			}
+		}
+	})
+
+	t.Run("unknown section", func(t *testing.T) {
+		data := resave(func(payload []byte) []byte {
+			return append(payload, 'N', 'E', 'X', 'T', 0, 0, 0, 2, 0xca, 0xfe)
+		})
+		loaded, err := neuron.LoadNet(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if got := loaded.String(); got != net.String() {
+			t.Fatalf("expected\n%v\ngot\n%v", net, got)
+		}
+	})
+
+	t.Run("invalid section", func(t *testing.T) {
+		// Bounds of the right size, but with their minimum above their maximum
+		data := resave(func(payload []byte) []byte {
+			body := payload[bytes.Index(payload, []byte("BNDS"))+8:]
+			binary.BigEndian.PutUint32(body, 5000)
+			return payload
+		})
+		_, err := neuron.LoadNet(bytes.NewReader(data))
+		isMalformed(t, err)
+	})
+}
+
+func TestLargeNet(t *testing.T) {
+	genes := make([]int, 70000)
+	for i := range genes {
+		genes[i] = i - 35000
+	}
+	large, err := neuron.NewNeuron(genes)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	t.Run("Marshal", func(t *testing.T) {
+		var buf bytes.Buffer
+		for value := range large.Marshal() {
+			buf.WriteByte(value)
+		}
+		data := buf.Bytes()
+		if got := len(data); got != 6+4*len(genes) {
+			t.Fatalf("expected %v bytes, got %v", 6+4*len(genes), got)
+		}
+		if data[0] != 0xff || data[1] != 0xff {
+			t.Fatalf("expected escaped size, got %v", data[:2])
+		}
+		if got := binary.BigEndian.Uint32(data[2:]); got != uint32(len(genes)) {
+			t.Fatalf("expected %v, got %v", len(genes), got)
+		}
+	})
+
+	sensors := make([]string, len(genes))
+	for i := range sensors {
+		sensors[i] = fmt.Sprintf("sensor %05d", i)
+	}
+	wide, err := neuron.NewNeuralNet(sensors, []string{"action"}, []neuron.Layer{{large}})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	deep, err := neuron.BuildNet(neuron.NewTopology([]string{"x"}, []string{"action"}, 70000))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for name, net := range map[string]neuron.NeuralNet{"wide": wide, "deep": deep} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := net.SaveFormat(&buf, neuron.Legacy); err == nil {
+				t.Fatalf("expected error not raised")
+			} else if _, ok := err.(*neuron.OverflowError); !ok {
+				t.Fatalf("expected *neuron.OverflowError, got %T", err)
+			}
+
+			if err := net.Save(&buf); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if buf.Len() <= 0xffff {
+				t.Fatalf("expected more than 64 KiB, got %v bytes", buf.Len())
+			}
+			loaded, err := neuron.LoadNet(&buf)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if got := loaded.String(); got != net.String() {
+				t.Fatalf("%v net expected to round-trip", name)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	net, err := neuron.BuildNet(neuron.Topology{
+		Sensors: []string{"x", "y"},
+		Actions: []string{"left", "right"},
+		Hidden:  []neuron.LayerSpec{{Width: 3}},
+		Rand:    rand.New(rand.NewSource(0)),
+	}, neuron.WithActivations(neuron.Tanh, neuron.Sigmoid))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	neu, _ := neuron.NewNeuron([]int{-5, 0, 5, 1 << 20})
+	var marshalled bytes.Buffer
+	for value := range neu.Marshal() {
+		marshalled.WriteByte(value)
+	}
+
+	isDecodeError := func(t *testing.T, err, expected error) {
+		if _, ok := err.(*neuron.DecodeError); !ok {
+			t.Fatalf("expected *neuron.DecodeError, got %T (%v)", err, err)
+		}
+		if !errors.Is(err, expected) {
+			t.Fatalf("expected %v, got %v", expected, err)
+		}
+	}
+
+	t.Run("short reads", func(t *testing.T) {
+		for _, format := range []neuron.Format{neuron.Legacy, neuron.V2} {
+			var buf bytes.Buffer
+			net.SaveFormat(&buf, format)
+			loaded, err := neuron.LoadNet(iotest.OneByteReader(&buf))
+			if err != nil {
+				t.Fatalf("format %v: unexpected error %v", format, err)
+			}
+			if got := loaded.String(); got != net.String() {
+				t.Fatalf("format %v: expected\n%v\ngot\n%v", format, net, got)
+			}
+		}
+		loaded, err := neuron.NewNeuron(iotest.HalfReader(bytes.NewReader(marshalled.Bytes())))
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if !loaded.Equals(neu) {
+			t.Fatalf("expected %v, got %v", neu, loaded)
+		}
+	})
+
+	t.Run("large neuron", func(t *testing.T) {
+		genes := make([]int, 70000)
+		for i := range genes {
+			genes[i] = i - 35000
+		}
+		large, _ := neuron.NewNeuron(genes)
+		loaded, err := neuron.NewNeuron(large.String())
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if !loaded.Equals(large) {
+			t.Fatalf("neuron of %v genes expected to round-trip", large.GetSize())
+		}
+		var buf bytes.Buffer
+		for value := range large.Marshal() {
+			buf.WriteByte(value)
+		}
+		loaded, err = neuron.NewNeuron(io.Reader(&buf))
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if !loaded.Equals(large) {
+			t.Fatalf("neuron of %v genes expected to round-trip", large.GetSize())
+		}
+	})
+
+	t.Run("neuron of 65535 genes", func(t *testing.T) {
+		genes := make([]int, 0xffff)
+		for i := range genes {
+			genes[i] = i%2000 - 1000
+		}
+		// A first gene that could pass for an escaped size
+		genes[0] = 70000
+		expected, _ := neuron.NewNeuron(genes)
+		data, err := expected.(encoding.BinaryMarshaler).MarshalBinary()
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if size := binary.BigEndian.Uint32(data[2:]); data[0] != 0xff || data[1] != 0xff || size != 0xffff {
+			t.Fatalf("expected neurons of 65535 genes marshalled with an escaped size, got %v", size)
+		}
+		for _, input := range []interface{}{
+			data,
+			append(append([]byte{}, data...), 0, 0, 0, 1),
+			io.Reader(bytes.NewReader(data)),
+			io.Reader(bytes.NewReader(append(append([]byte{}, data...), 0, 0, 0, 1))),
+		} {
+			loaded, err := neuron.NewNeuron(input)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !loaded.Equals(expected) {
+				t.Fatalf("neuron of %v genes expected to decode from %T", expected.GetSize(), input)
+			}
+		}
+	})
+
+	t.Run("truncated neuron", func(t *testing.T) {
+		data := marshalled.Bytes()
+		for size := 0; size < len(data); size++ {
+			_, err := neuron.NewNeuron(data[:size])
+			isDecodeError(t, err, neuron.ErrTruncated)
+			_, err = neuron.NewNeuron(io.Reader(bytes.NewReader(data[:size])))
+			isDecodeError(t, err, neuron.ErrTruncated)
+		}
+		// A huge announced size must not be trusted
+		_, err := neuron.NewNeuron(io.Reader(bytes.NewReader([]byte{0xff, 0xff, 0x10, 0, 0, 0, 0, 0, 0, 1})))
+		isDecodeError(t, err, neuron.ErrTruncated)
+	})
+
+	t.Run("malformed neuron", func(t *testing.T) {
+		// Escaped sizes must need the escape and fit the format
+		for _, data := range [][]byte{
+			{0xff, 0xff, 0, 0, 0, 1, 0, 0, 0, 1},
+			{0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		} {
+			_, err := neuron.NewNeuron(data)
+			isDecodeError(t, err, neuron.ErrMalformed)
+			_, err = neuron.NewNeuron(io.Reader(bytes.NewReader(data)))
+			isDecodeError(t, err, neuron.ErrMalformed)
+		}
+		var value neuron.NeuronValue
+		isDecodeError(t, value.UnmarshalBinary([]byte{0, 1, 0, 0, 0, 1, 9}), neuron.ErrMalformed)
+	})
+
+	t.Run("truncated net", func(t *testing.T) {
+		for _, format := range []neuron.Format{neuron.Legacy, neuron.V2} {
+			var buf bytes.Buffer
+			net.SaveFormat(&buf, format)
+			data := buf.Bytes()
+			if _, err := neuron.LoadNet(bytes.NewReader(nil)); err != io.EOF {
+				t.Fatalf("expected %v, got %v", io.EOF, err)
+			}
+			accepted := 0
+			for size := 1; size < len(data); size++ {
+				_, err := neuron.LoadNet(bytes.NewReader(data[:size]))
+				if format == neuron.Legacy && err == nil {
+					// Legacy streams ending right after the neurons are
+					// accepted, having neither sections nor tail
+					accepted++
+					continue
+				}
+				isDecodeError(t, err, neuron.ErrTruncated)
+			}
+			if accepted > 1 {
+				t.Fatalf("format %v: %v truncated streams accepted", format, accepted)
+			}
+		}
+	})
+
+	t.Run("garbage", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(0))
+		for i := 0; i < 2000; i++ {
+			data := make([]byte, rng.Intn(200))
+			rng.Read(data)
+			if len(data) >= 4 && i%2 == 0 {
+				// Enter the legacy decoder
+				data[2], data[3] = 0, 0
+			}
+			if _, err := neuron.LoadNet(bytes.NewReader(data)); err == nil {
+				t.Fatalf("%v: expected error not raised", data)
+			}
+			neuron.NewNeuron(data)
+		}
+	})
+}
@@ -0,0 +1,172 @@
+package tests
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/cacilhas/neuron/neuron"
+)
+
+func TestInitialiser(t *testing.T) {
+	t.Run("Uniform", func(t *testing.T) {
+		genes := neuron.Uniform(-5, 5)(rand.New(rand.NewSource(0)), 10, 10)
+		for _, row := range genes {
+			for _, gene := range row {
+				if gene < -5 || gene >= 5 {
+					t.Fatalf("gene %v out of range", gene)
+				}
+			}
+		}
+	})
+
+	t.Run("Uniform wide range", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(0))
+		for _, bounds := range [][2]int{{-1 << 62, 1 << 62}, {math.MinInt64, math.MaxInt64}} {
+			for _, row := range neuron.Uniform(bounds[0], bounds[1])(rng, 10, 10) {
+				for _, gene := range row {
+					if gene < bounds[0] || gene >= bounds[1] {
+						t.Fatalf("gene %v out of range %v", gene, bounds)
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("Uniform invalid range", func(t *testing.T) {
+		topology := neuron.NewTopology([]string{"x"}, []string{"a"}, 2)
+		topology.Hidden[0].Initialiser = neuron.Uniform(5, 5)
+		if _, err := neuron.BuildNet(topology); err == nil {
+			t.Fatalf("expected error not raised")
+		}
+		if _, err := neuron.NewNeuron(neuron.NeuronSpec{Size: 3, Initialiser: neuron.Uniform(5, -5)}); err == nil {
+			t.Fatalf("expected error not raised")
+		}
+		if _, err := neuron.NewNeuron(neuron.NeuronSpec{Size: 3, FanOut: -1}); err == nil {
+			t.Fatalf("expected error not raised for a negative fan-out")
+		}
+	})
+
+	t.Run("Constant", func(t *testing.T) {
+		for _, row := range neuron.Constant(7)(nil, 3, 2) {
+			for _, gene := range row {
+				if gene != 7 {
+					t.Fatalf("expected 7, got %v", gene)
+				}
+			}
+		}
+	})
+
+	t.Run("Gaussian", func(t *testing.T) {
+		genes := neuron.Gaussian(100)(rand.New(rand.NewSource(0)), 100, 100)
+		if got := std(genes); got < 90 || got > 110 {
+			t.Fatalf("expected std close to 100, got %v", got)
+		}
+	})
+
+	t.Run("Xavier", func(t *testing.T) {
+		genes := neuron.Xavier()(rand.New(rand.NewSource(0)), 100, 100)
+		if got := std(genes); got < 90 || got > 110 {
+			t.Fatalf("expected std close to 100, got %v", got)
+		}
+	})
+
+	t.Run("He", func(t *testing.T) {
+		genes := neuron.He()(rand.New(rand.NewSource(0)), 100, 200)
+		if got := std(genes); got < 90 || got > 110 {
+			t.Fatalf("expected std close to 100, got %v", got)
+		}
+	})
+
+	t.Run("Orthogonal", func(t *testing.T) {
+		for _, shape := range [][2]int{{3, 5}, {5, 3}} {
+			genes := neuron.Orthogonal(1)(rand.New(rand.NewSource(0)), shape[0], shape[1])
+			if len(genes) != shape[0] || len(genes[0]) != shape[1] {
+				t.Fatalf("unexpected shape %vx%v", len(genes), len(genes[0]))
+			}
+			vectors := genes
+			if shape[0] > shape[1] {
+				vectors = transpose(genes)
+			}
+			for i := range vectors {
+				for j := range vectors {
+					dot := 0.0
+					for k := range vectors[i] {
+						dot += float64(vectors[i][k]) * float64(vectors[j][k])
+					}
+					expected := 0.0
+					if i == j {
+						expected = neuron.GeneScale * neuron.GeneScale
+					}
+					if math.Abs(dot-expected) > 5000 {
+						t.Fatalf("%v: vectors %v and %v: expected dot %v, got %v", shape, i, j, expected, dot)
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("seeded", func(t *testing.T) {
+		spec := neuron.NeuronSpec{Size: 5, Initialiser: neuron.Xavier(), Rand: rand.New(rand.NewSource(42))}
+		first, err := neuron.NewNeuron(spec)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		spec.Rand = rand.New(rand.NewSource(42))
+		second, _ := neuron.NewNeuron(spec)
+		if !first.Equals(second) {
+			t.Fatalf("expected %v, got %v", first, second)
+		}
+		if got := first.GetSize(); got != 5 {
+			t.Fatalf("expected 5, got %v", got)
+		}
+	})
+
+	t.Run("BuildNet", func(t *testing.T) {
+		build := func() neuron.NeuralNet {
+			topology := neuron.NewTopology([]string{"a", "b"}, []string{"x"}, 4)
+			topology.Hidden[0].Initialiser = neuron.He()
+			topology.Output.Initialiser = neuron.Uniform(-10, 10)
+			topology.Rand = rand.New(rand.NewSource(7))
+			net, err := neuron.BuildNet(topology)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			return net
+		}
+		if first, second := build().String(), build().String(); first != second {
+			t.Fatalf("expected\n%v\ngot\n%v", first, second)
+		}
+		for _, neu := range build().GetNeurons(1) {
+			for i := 0; i < neu.GetSize(); i++ {
+				if gene := neu.GetGene(i); gene < -10 || gene >= 10 {
+					t.Fatalf("gene %v out of range", gene)
+				}
+			}
+		}
+	})
+}
+
+func std(genes [][]int) float64 {
+	sum, squares, count := 0.0, 0.0, 0.0
+	for _, row := range genes {
+		for _, gene := range row {
+			sum += float64(gene)
+			squares += float64(gene) * float64(gene)
+			count++
+		}
+	}
+	mean := sum / count
+	return math.Sqrt(squares/count - mean*mean)
+}
+
+func transpose(genes [][]int) [][]int {
+	res := make([][]int, len(genes[0]))
+	for j := range res {
+		res[j] = make([]int, len(genes))
+		for i := range genes {
+			res[j][i] = genes[i][j]
+		}
+	}
+	return res
+}
@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/cacilhas/neuron/neuron"
+)
+
+func TestJSON(t *testing.T) {
+	t.Run("Neuron", func(t *testing.T) {
+		neu, _ := neuron.NewNeuron([]int{-5, 0, 5})
+		data, err := json.Marshal(neu)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if got := string(data); got != "[-5,0,5]" {
+			t.Fatalf("expected [-5,0,5], got %v", got)
+		}
+		var value neuron.NeuronValue
+		if err := json.Unmarshal(data, &value); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if !value.Equals(neu) {
+			t.Fatalf("expected %v, got %v", neu, value.Neuron)
+		}
+		for _, doc := range []string{`[1, "a"]`, `{}`, `[4294967296]`} {
+			if err := json.Unmarshal([]byte(doc), &value); err == nil {
+				t.Fatalf("%v: expected error not raised", doc)
+			}
+		}
+	})
+
+	t.Run("NeuralNet", func(t *testing.T) {
+		net, err := neuron.NewNeuralNet(
+			[]string{"x", "y"},
+			[]string{"go"},
+			[]neuron.Layer{{getGenes(t, 1, -1)}},
+		)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		data, err := json.Marshal(net)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		expected := `{"sensors":["x","y"],"actions":["go"],"layers":[[[1,-1]]]}`
+		if got := string(data); got != expected {
+			t.Fatalf("expected %v, got %v", expected, got)
+		}
+	})
+
+	t.Run("settings", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(0))
+		one := 1.0
+		net, err := neuron.BuildNet(neuron.Topology{
+			Sensors: []string{"x", "y"},
+			Actions: []string{"left", "right"},
+			Hidden:  []neuron.LayerSpec{{Width: 3, Activation: neuron.Tanh}},
+			Rand:    rng,
+		},
+			neuron.WithBounds(neuron.Bounds{Min: -2000, Max: 2000, Mode: neuron.Reflect}),
+			neuron.WithNormalisers(map[string]neuron.Normaliser{"x": {Method: neuron.Standard, Mean: 2, Std: 3}}),
+			neuron.WithSchema(neuron.Schema{"y": {Unit: "m", Min: 0, Max: 10, Default: &one}}),
+			neuron.WithFrozenNeurons(neuron.NeuronRef{Layer: 0, Neuron: 2}),
+		)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		other, _ := neuron.BuildNet(neuron.Topology{
+			Sensors: []string{"z"},
+			Actions: []string{"jump"},
+			Hidden:  []neuron.LayerSpec{{Width: 1, Activation: neuron.Tanh}},
+			Rand:    rng,
+		}, neuron.WithBounds(neuron.Bounds{Min: -2000, Max: 2000, Mode: neuron.Reflect}))
+		merged, err := neuron.Merge(net, other)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		for n, current := range []neuron.NeuralNet{net, merged} {
+			data, err := json.Marshal(current)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if n > 0 && !strings.Contains(string(data), `"parts":[{`) {
+				t.Fatalf("parts missing from %v", string(data))
+			}
+			for _, field := range []string{`"activations":["tanh","relu"]`, `"mode":"reflect"`, `"method":"standard"`, `"unit":"m"`, `"frozen":[{"layer":0,"neuron":2}]`} {
+				if !strings.Contains(string(data), field) {
+					t.Fatalf("%v missing from %v", field, string(data))
+				}
+			}
+			var value neuron.NetValue
+			if err := json.Unmarshal(data, &value); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if got := value.String(); got != current.String() {
+				t.Fatalf("expected\n%v\ngot\n%v", current, got)
+			}
+		}
+		if _, err := neuron.Split(merged); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		cases := map[string]string{
+			`{"sensors":["x"],"actions":["go"],"layers":[[[1,2]]]}`:                            "expected size 1, got 2",
+			`{"sensors":["x"],"actions":["go"],"layers":[]}`:                                   "no neuron supplied",
+			`{"sensors":["x"],"actions":["go"],"layers":[[[1]]],"activations":["softmax"]}`:    `unknown activation "softmax"`,
+			`{"sensors":["x"],"actions":["go"],"layers":[[[1]]],"colour":"red"}`:               `unknown field "colour"`,
+			`{"sensors":["x"],"actions":["go"],"layers":[[[5]]],"bounds":[{"min":0,"max":1}]}`: "value 5 out of bounds",
+		}
+		for doc, message := range cases {
+			var value neuron.NetValue
+			err := json.Unmarshal([]byte(doc), &value)
+			if err == nil || !strings.Contains(err.Error(), message) {
+				t.Fatalf("%v: expected %q, got %v", doc, message, err)
+			}
+		}
+		var value neuron.NetValue
+		err := json.Unmarshal([]byte(`{"sensors":["x"],"actions":["go"],"layers":[[[5]]],"bounds":[{"min":0,"max":1}]}`), &value)
+		var rangeErr *neuron.GeneRangeError
+		if !errors.As(err, &rangeErr) || rangeErr.Value != 5 {
+			t.Fatalf("expected *neuron.GeneRangeError, got %v", err)
+		}
+	})
+}
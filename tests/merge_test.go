@@ -0,0 +1,197 @@
+package tests
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/cacilhas/neuron/neuron"
+)
+
+func TestMerge(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	navigation, err := neuron.BuildNet(neuron.Topology{
+		Sensors: []string{"x", "y"},
+		Actions: []string{"left", "right"},
+		Hidden:  []neuron.LayerSpec{{Width: 3}, {Width: 2}},
+		Rand:    rng,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	combat, err := neuron.BuildNet(neuron.Topology{
+		Sensors: []string{"enemy", "y"},
+		Actions: []string{"shoot"},
+		Rand:    rng,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	merged, err := neuron.Merge(navigation, combat)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	t.Run("GetSensors", func(t *testing.T) {
+		expected := "enemy, x, y"
+		if got := strings.Join(merged.GetSensors(), ", "); got != expected {
+			t.Fatalf("expected %v, got %v", expected, got)
+		}
+	})
+
+	t.Run("GetActions", func(t *testing.T) {
+		expected := "left, right, shoot"
+		if got := strings.Join(merged.GetActions(), ", "); got != expected {
+			t.Fatalf("expected %v, got %v", expected, got)
+		}
+	})
+
+	compare := func(t *testing.T, merged neuron.NeuralNet, parts ...neuron.NeuralNet) {
+		for i := 0; i < 100; i++ {
+			input := map[string]float64{
+				"enemy": rng.Float64()*200 - 100,
+				"x":     rng.Float64()*200 - 100,
+				"y":     rng.Float64()*200 - 100,
+			}
+			got, err := merged.Activate(input)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			for _, part := range parts {
+				partInput := make(map[string]float64)
+				for _, sensor := range part.GetSensors() {
+					partInput[sensor] = input[sensor]
+				}
+				expected, _ := part.Activate(partInput)
+				for action, value := range expected {
+					if got[action] != value {
+						t.Fatalf("%v: %v expected %v, got %v", input, action, value, got[action])
+					}
+				}
+			}
+		}
+	}
+
+	t.Run("Activate", func(t *testing.T) {
+		compare(t, merged, navigation, combat)
+	})
+
+	t.Run("Split", func(t *testing.T) {
+		parts, err := neuron.Split(merged)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if len(parts) != 2 {
+			t.Fatalf("expected 2 parts, got %v", len(parts))
+		}
+		if got := parts[0].String(); got != navigation.String() {
+			t.Fatalf("expected\n%v\ngot\n%v", navigation, got)
+		}
+		if got := parts[1].String(); got != combat.String() {
+			t.Fatalf("expected\n%v\ngot\n%v", combat, got)
+		}
+		if _, err := neuron.Split(navigation); err == nil {
+			t.Fatalf("expected error not raised")
+		}
+	})
+
+	t.Run("GetChild", func(t *testing.T) {
+		child := merged.GetChild(200)
+		parts, err := neuron.Split(child)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if parts[0].String() == navigation.String() {
+			t.Fatalf("navigation expected to evolve")
+		}
+		compare(t, child, parts...)
+	})
+
+	t.Run("surgery", func(t *testing.T) {
+		grown, err := neuron.AddSensor(merged, "z")
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		parts, err := neuron.Split(grown)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if parts[0].String() != navigation.String() || parts[1].String() != combat.String() {
+			t.Fatalf("expected the original parts, got\n%v\n%v", parts[0], parts[1])
+		}
+
+		blind, err := neuron.RemoveSensor(merged, "enemy")
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if parts, err = neuron.Split(blind); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if got := strings.Join(parts[1].GetSensors(), ", "); got != "y" {
+			t.Fatalf("expected combat to read y only, got %v", got)
+		}
+		input := map[string]float64{"x": 3, "y": -2}
+		got, err := blind.Activate(input)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		for _, part := range parts {
+			partInput := make(map[string]float64)
+			for _, sensor := range part.GetSensors() {
+				partInput[sensor] = input[sensor]
+			}
+			expected, err := part.Activate(partInput)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			for action, value := range expected {
+				if got[action] != value {
+					t.Fatalf("%v: expected %v, got %v", action, value, got[action])
+				}
+			}
+		}
+
+		if _, err := neuron.AddAction(merged, "jump", nil); err == nil {
+			t.Fatalf("expected error not raised")
+		}
+		if _, err := neuron.RemoveAction(merged, "shoot"); err == nil {
+			t.Fatalf("expected error not raised")
+		}
+		if _, _, err := neuron.Prune(merged, neuron.PruneOptions{}); err == nil {
+			t.Fatalf("expected error not raised")
+		}
+	})
+
+	t.Run("LoadNet", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := merged.Save(&buf); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		loaded, err := neuron.LoadNet(&buf)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		parts, err := neuron.Split(loaded)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if got := parts[1].String(); got != combat.String() {
+			t.Fatalf("expected\n%v\ngot\n%v", combat, got)
+		}
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		if _, err := neuron.Merge(navigation, navigation); err == nil {
+			t.Fatalf("expected error not raised")
+		}
+		sigmoid, _ := neuron.BuildNet(neuron.Topology{
+			Sensors: []string{"z"},
+			Actions: []string{"duck"},
+			Output:  neuron.LayerSpec{Activation: neuron.Sigmoid},
+		})
+		if _, err := neuron.Merge(navigation, sigmoid); err == nil {
+			t.Fatalf("expected error not raised")
+		}
+	})
+}
@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cacilhas/neuron/neuron"
+)
+
+func TestMetadata(t *testing.T) {
+	metadata := neuron.Metadata{
+		neuron.MetaID:         "net-7",
+		neuron.MetaGeneration: "12",
+		neuron.MetaFitness:    "0.93",
+		neuron.MetaParents:    "net-3,net-4",
+		neuron.MetaRun:        "run: 2024/05",
+		neuron.MetaCreated:    "2024-05-01T12:00:00Z",
+		neuron.MetaTags:       "walker, \"stable\"\nbaseline",
+	}
+	layers := []neuron.Layer{{getGenes(t, -1, 1)}}
+	net, err := neuron.NewNeuralNet([]string{"x", "y"}, []string{"go"}, layers, neuron.WithMetadata(metadata))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	t.Run("GetMetadata", func(t *testing.T) {
+		got := net.GetMetadata()
+		if !reflect.DeepEqual(got, metadata) {
+			t.Fatalf("expected %v, got %v", metadata, got)
+		}
+		got[neuron.MetaID] = "changed"
+		if net.GetMetadata()[neuron.MetaID] != "net-7" {
+			t.Fatalf("metadata expected to be copied")
+		}
+		plain, _ := neuron.NewNeuralNet([]string{"x", "y"}, []string{"go"}, layers)
+		if got := plain.GetMetadata(); len(got) != 0 {
+			t.Fatalf("expected no metadata, got %v", got)
+		}
+		if _, err := neuron.NewNeuralNet([]string{"x", "y"}, []string{"go"}, layers, neuron.WithMetadata(neuron.Metadata{"": "x"})); err == nil {
+			t.Fatalf("expected error not raised")
+		}
+	})
+
+	t.Run("GetChild", func(t *testing.T) {
+		expected := neuron.Metadata{
+			neuron.MetaGeneration: "13",
+			neuron.MetaParents:    "net-7",
+			neuron.MetaRun:        "run: 2024/05",
+			neuron.MetaTags:       "walker, \"stable\"\nbaseline",
+		}
+		child := net.GetChild(10)
+		if got := child.GetMetadata(); !reflect.DeepEqual(got, expected) {
+			t.Fatalf("expected %v, got %v", expected, got)
+		}
+		delete(expected, neuron.MetaParents)
+		expected[neuron.MetaGeneration] = "14"
+		if got := child.GetChild(10).GetMetadata(); !reflect.DeepEqual(got, expected) {
+			t.Fatalf("expected %v, got %v", expected, got)
+		}
+	})
+
+	t.Run("AddSensor", func(t *testing.T) {
+		grown, err := neuron.AddSensor(net, "z")
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if got := grown.GetMetadata(); !reflect.DeepEqual(got, metadata) {
+			t.Fatalf("expected %v, got %v", metadata, got)
+		}
+	})
+
+	t.Run("Save", func(t *testing.T) {
+		for _, format := range []neuron.Format{neuron.Legacy, neuron.V2} {
+			var buf bytes.Buffer
+			if err := net.SaveFormat(&buf, format); err != nil {
+				t.Fatalf("format %v: unexpected error %v", format, err)
+			}
+			loaded, err := neuron.LoadNet(&buf)
+			if err != nil {
+				t.Fatalf("format %v: unexpected error %v", format, err)
+			}
+			if got := loaded.GetMetadata(); !reflect.DeepEqual(got, metadata) {
+				t.Fatalf("format %v: expected %v, got %v", format, metadata, got)
+			}
+		}
+		var buf bytes.Buffer
+		net.SaveCompressed(&buf, gzip.BestSpeed)
+		loaded, err := neuron.LoadNet(&buf)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if got := loaded.GetMetadata(); !reflect.DeepEqual(got, metadata) {
+			t.Fatalf("expected %v, got %v", metadata, got)
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		data, err := json.Marshal(net)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		var value neuron.NetValue
+		if err := json.Unmarshal(data, &value); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if got := value.GetMetadata(); !reflect.DeepEqual(got, metadata) {
+			t.Fatalf("expected %v, got %v", metadata, got)
+		}
+	})
+
+	t.Run("String", func(t *testing.T) {
+		text := net.String()
+		for _, line := range []string{
+			`META created: "2024-05-01T12:00:00Z"`,
+			`META generation: "12"`,
+			`META run: "run: 2024/05"`,
+			`META tags: "walker, \"stable\"\nbaseline"`,
+		} {
+			if !strings.Contains(text, "\n"+line+"\n") {
+				t.Fatalf("expected %q in\n%v", line, text)
+			}
+		}
+		parsed, err := neuron.ParseNet(text)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if got := parsed.GetMetadata(); !reflect.DeepEqual(got, metadata) {
+			t.Fatalf("expected %v, got %v", metadata, got)
+		}
+		for _, text := range []string{
+			"META id: net-7\nSENSORS: x, y\nACTIONS: go\nNEURONS:\n-1 1\n\n-----\n",
+			"META id: \"a\"\nMETA id: \"b\"\nSENSORS: x, y\nACTIONS: go\nNEURONS:\n-1 1\n\n-----\n",
+		} {
+			if _, err := neuron.ParseNet(text); err == nil {
+				t.Fatalf("%q: expected error not raised", text)
+			}
+		}
+	})
+}
@@ -2,8 +2,11 @@ package tests
 
 import (
 	"bytes"
+	"encoding"
 	"encoding/base32"
+	"errors"
 	"io"
+	"io/ioutil"
 	"math/rand"
 	"strings"
 	"testing"
@@ -82,7 +85,7 @@ func TestNet(t *testing.T) {
 		})
 
 		t.Run("String", func(t *testing.T) {
-			expected := "SENSORS: sensor 1, sensor 2, sensor 3\nACTIONS: action 1, action 2\nNEURONS:\n001G00012BVVVVGQ00002O8\n001VVVVU280000G3VVVVS20\n\n001FVVVV2S00002D\n0010000143VVVVTO\n\n-----\n"
+			expected := "SENSORS: sensor 1, sensor 2, sensor 3\nACTIONS: action 1, action 2\nNEURONS:\n274 -486 353\n-494 515 -504\n\n-233 77\n288 -72\n\n-----\n"
 			if got := net.String(); got != expected {
 				t.Fatalf("expected\n%v\ngot\n%v", expected, got)
 			}
@@ -91,7 +94,7 @@ func TestNet(t *testing.T) {
 		t.Run("Save", func(t *testing.T) {
 			r, w := io.Pipe()
 			go func() {
-				net.Save(w)
+				net.SaveFormat(w, neuron.Legacy)
 				w.Close()
 			}()
 			var buf bytes.Buffer
@@ -156,7 +159,7 @@ func TestNet(t *testing.T) {
 
 		t.Run("GetChild", func(t *testing.T) {
 			child := net.GetChild(100)
-			expected := "SENSORS: sensor 1, sensor 2, sensor 3\nACTIONS: action 1, action 2\nNEURONS:\n001G00014JVVVVGN00002QG\n001VVVVU200000G5VVVVRS0\n\n001FVVVUU000002D\n0010000143VVVVV4\n\n-----\n"
+			expected := "SENSORS: sensor 1, sensor 2, sensor 3\nACTIONS: action 1, action 2\nNEURONS:\n292 -489 362\n-496 517 -528\n\n-272 77\n288 -28\n\n-----\n"
 			if got := child.String(); got != expected {
 				t.Fatalf("expected:\n%v\ngot:%v", expected, got)
 			}
@@ -171,3 +174,108 @@ func getNeuron(t *testing.T, data int) neuron.Neuron {
 	}
 	return neu
 }
+
+func TestNetBinary(t *testing.T) {
+	net, err := neuron.BuildNet(neuron.Topology{
+		Sensors: []string{"x", "y"},
+		Actions: []string{"left", "right"},
+		Hidden:  []neuron.LayerSpec{{Width: 3}},
+		Rand:    rand.New(rand.NewSource(0)),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	var _ encoding.BinaryMarshaler = net
+	var _ io.WriterTo = net
+	var _ encoding.BinaryUnmarshaler = &neuron.NetValue{}
+
+	var saved bytes.Buffer
+	net.Save(&saved)
+
+	t.Run("MarshalBinary", func(t *testing.T) {
+		data, err := net.MarshalBinary()
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if !bytes.Equal(data, saved.Bytes()) {
+			t.Fatalf("expected the bytes written by Save")
+		}
+	})
+
+	t.Run("WriteTo", func(t *testing.T) {
+		var buf bytes.Buffer
+		written, err := net.WriteTo(&buf)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if written != int64(saved.Len()) || !bytes.Equal(buf.Bytes(), saved.Bytes()) {
+			t.Fatalf("expected the bytes written by Save, got %v bytes", written)
+		}
+	})
+
+	t.Run("UnmarshalBinary", func(t *testing.T) {
+		var legacy bytes.Buffer
+		net.SaveFormat(&legacy, neuron.Legacy)
+		for _, data := range [][]byte{saved.Bytes(), legacy.Bytes()} {
+			var value neuron.NetValue
+			if err := value.UnmarshalBinary(data); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if got := value.String(); got != net.String() {
+				t.Fatalf("expected\n%v\ngot\n%v", net, got)
+			}
+		}
+		var value neuron.NetValue
+		if err := value.UnmarshalBinary(append(saved.Bytes(), 0)); !errors.Is(err, neuron.ErrMalformed) {
+			t.Fatalf("expected %v, got %v", neuron.ErrMalformed, err)
+		}
+		if err := value.UnmarshalBinary(nil); !errors.Is(err, neuron.ErrTruncated) {
+			t.Fatalf("expected %v, got %v", neuron.ErrTruncated, err)
+		}
+	})
+}
+
+func benchmarkNet(b *testing.B) neuron.NeuralNet {
+	net, err := neuron.BuildNet(neuron.Topology{
+		Sensors: []string{"a", "b", "c", "d", "e", "f", "g", "h"},
+		Actions: []string{"x", "y"},
+		Hidden:  []neuron.LayerSpec{{Width: 64}, {Width: 64}},
+		Rand:    rand.New(rand.NewSource(0)),
+	})
+	if err != nil {
+		b.Fatalf("unexpected error %v", err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	return net
+}
+
+func BenchmarkNetSaveLegacy(b *testing.B) {
+	net := benchmarkNet(b)
+	for i := 0; i < b.N; i++ {
+		net.SaveFormat(ioutil.Discard, neuron.Legacy)
+	}
+}
+
+func BenchmarkNetWriteTo(b *testing.B) {
+	net := benchmarkNet(b)
+	for i := 0; i < b.N; i++ {
+		net.WriteTo(ioutil.Discard)
+	}
+}
+
+func BenchmarkNetUnmarshalBinary(b *testing.B) {
+	data, _ := benchmarkNet(b).MarshalBinary()
+	b.ResetTimer()
+	var value neuron.NetValue
+	for i := 0; i < b.N; i++ {
+		value.UnmarshalBinary(data)
+	}
+}
+
+func BenchmarkNetString(b *testing.B) {
+	net := benchmarkNet(b)
+	for i := 0; i < b.N; i++ {
+		_ = net.String()
+	}
+}
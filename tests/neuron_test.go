@@ -2,8 +2,13 @@ package tests
 
 import (
 	"bytes"
+	"encoding"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"math/rand"
 	"testing"
 
@@ -201,3 +206,174 @@ func TestNeuron(t *testing.T) {
 		}
 	})
 }
+
+// marshaler is implemented by the neurons NewNeuron creates, though not
+// required by the Neuron interface
+type marshaler interface {
+	neuron.Neuron
+	encoding.BinaryMarshaler
+	io.WriterTo
+	json.Marshaler
+}
+
+func asMarshaler(t testing.TB, neu neuron.Neuron) marshaler {
+	res, ok := neu.(marshaler)
+	if !ok {
+		t.Fatalf("%T expected to implement marshalling", neu)
+	}
+	return res
+}
+
+func TestNeuronBinary(t *testing.T) {
+	created, _ := neuron.NewNeuron([]int{-5, -4, -3, -2, -1, 0, 1, 2, 3, 4})
+	neu := asMarshaler(t, created)
+	var _ encoding.BinaryUnmarshaler = &neuron.NeuronValue{}
+
+	var drained bytes.Buffer
+	for value := range neu.Marshal() {
+		drained.WriteByte(value)
+	}
+
+	t.Run("MarshalBinary", func(t *testing.T) {
+		data, err := neu.MarshalBinary()
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if !bytes.Equal(data, drained.Bytes()) {
+			t.Fatalf("expected %v, got %v", drained.Bytes(), data)
+		}
+	})
+
+	t.Run("WriteTo", func(t *testing.T) {
+		var buf bytes.Buffer
+		written, err := neu.WriteTo(&buf)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if written != int64(drained.Len()) || !bytes.Equal(buf.Bytes(), drained.Bytes()) {
+			t.Fatalf("expected %v, got %v (%v bytes)", drained.Bytes(), buf.Bytes(), written)
+		}
+	})
+
+	t.Run("UnmarshalBinary", func(t *testing.T) {
+		var value neuron.NeuronValue
+		if err := value.UnmarshalBinary(drained.Bytes()); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if !value.Equals(created) {
+			t.Fatalf("expected %v, got %v", created, value.Neuron)
+		}
+		if err := value.UnmarshalBinary(append(drained.Bytes(), 0)); !errors.Is(err, neuron.ErrMalformed) {
+			t.Fatalf("expected %v, got %v", neuron.ErrMalformed, err)
+		}
+		if err := value.UnmarshalBinary(drained.Bytes()[:5]); !errors.Is(err, neuron.ErrTruncated) {
+			t.Fatalf("expected %v, got %v", neuron.ErrTruncated, err)
+		}
+	})
+}
+
+// goroutineMarshal stream a neuron a byte at a time, as Marshal used to
+func goroutineMarshal(neu neuron.Neuron) <-chan byte {
+	ch := make(chan byte)
+	go func() {
+		defer close(ch)
+		var buf [4]byte
+		binary.BigEndian.PutUint16(buf[:], uint16(neu.GetSize()))
+		ch <- buf[0]
+		ch <- buf[1]
+		for i := 0; i < neu.GetSize(); i++ {
+			binary.BigEndian.PutUint32(buf[:], uint32(int32(neu.GetGene(i))))
+			for _, value := range buf {
+				ch <- value
+			}
+		}
+	}()
+	return ch
+}
+
+func benchmarkNeuron(b *testing.B) marshaler {
+	neu, err := neuron.NewNeuron(neuron.NeuronSpec{Size: 256, Rand: rand.New(rand.NewSource(0))})
+	if err != nil {
+		b.Fatalf("unexpected error %v", err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	return asMarshaler(b, neu)
+}
+
+func BenchmarkNeuronGoroutineMarshal(b *testing.B) {
+	neu := benchmarkNeuron(b)
+	for i := 0; i < b.N; i++ {
+		var buf bytes.Buffer
+		for value := range goroutineMarshal(neu) {
+			buf.WriteByte(value)
+		}
+	}
+}
+
+func BenchmarkNeuronMarshal(b *testing.B) {
+	neu := benchmarkNeuron(b)
+	for i := 0; i < b.N; i++ {
+		var buf bytes.Buffer
+		for value := range neu.Marshal() {
+			buf.WriteByte(value)
+		}
+	}
+}
+
+func BenchmarkNeuronMarshalBinary(b *testing.B) {
+	neu := benchmarkNeuron(b)
+	for i := 0; i < b.N; i++ {
+		neu.MarshalBinary()
+	}
+}
+
+func BenchmarkNeuronWriteTo(b *testing.B) {
+	neu := benchmarkNeuron(b)
+	for i := 0; i < b.N; i++ {
+		neu.WriteTo(ioutil.Discard)
+	}
+}
+
+func BenchmarkNeuronUnmarshalBinary(b *testing.B) {
+	data, _ := benchmarkNeuron(b).MarshalBinary()
+	b.ResetTimer()
+	var value neuron.NeuronValue
+	for i := 0; i < b.N; i++ {
+		value.UnmarshalBinary(data)
+	}
+}
+
+// plainNeuron implements Neuron outside the package, with no marshalling
+type plainNeuron []int
+
+func (neu plainNeuron) Compute(data ...float64) int { return 0 }
+func (neu plainNeuron) Equals(other neuron.Neuron) bool {
+	return other.GetSize() == len(neu) && (len(neu) == 0 || other.GetGene(0) == neu[0])
+}
+func (neu plainNeuron) GetSize() int            { return len(neu) }
+func (neu plainNeuron) GetGene(index int) int   { return neu[index] }
+func (neu plainNeuron) Marshal() <-chan byte    { return nil }
+func (neu plainNeuron) Child(int) neuron.Neuron { return neu }
+func (neu plainNeuron) String() string          { return fmt.Sprint([]int(neu)) }
+
+func TestOutsideNeuron(t *testing.T) {
+	net, err := neuron.NewNeuralNet([]string{"x", "y"}, []string{"a"}, []neuron.Layer{{plainNeuron{3, -4}}})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	var buf bytes.Buffer
+	if err := net.Save(&buf); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	loaded, err := neuron.LoadNet(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if got := loaded.GetNeurons(0)[0]; got.GetGene(0) != 3 || got.GetGene(1) != -4 {
+		t.Fatalf("expected genes 3, -4, got %v", got)
+	}
+	if data, err := json.Marshal(net); err != nil || !bytes.Contains(data, []byte("[3,-4]")) {
+		t.Fatalf("expected genes 3, -4 in %s, got error %v", data, err)
+	}
+}
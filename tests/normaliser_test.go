@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/cacilhas/neuron/neuron"
+)
+
+func TestNormaliser(t *testing.T) {
+	t.Run("Apply", func(t *testing.T) {
+		cases := []struct {
+			norm     neuron.Normaliser
+			input    float64
+			expected float64
+		}{
+			{neuron.Normaliser{Method: neuron.MinMax, Min: -10, Max: 10}, 5, 0.75},
+			{neuron.Normaliser{Method: neuron.MinMax, Min: -10, Max: 10}, 30, 2},
+			{neuron.Normaliser{Method: neuron.MinMax, Min: -10, Max: 10, Clip: true}, 30, 1},
+			{neuron.Normaliser{Method: neuron.Standard, Mean: 100, Std: 20}, 140, 2},
+			{neuron.Normaliser{Method: neuron.Standard, Mean: 100, Std: 20, Min: 80, Max: 120, Clip: true}, 140, 1},
+		}
+		for _, c := range cases {
+			if got := c.norm.Apply(c.input); math.Abs(got-c.expected) > 1e-9 {
+				t.Fatalf("%v.Apply(%v): expected %v, got %v", c.norm, c.input, c.expected, got)
+			}
+		}
+	})
+
+	t.Run("FitNormalisers", func(t *testing.T) {
+		samples := []map[string]float64{
+			{"distance": -12, "height": 246.128},
+			{"distance": 8, "height": 250},
+			{"distance": 4, "height": 250},
+		}
+		normalisers, err := neuron.FitNormalisers(neuron.MinMax, true, samples)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		distance := normalisers["distance"]
+		if distance.Min != -12 || distance.Max != 8 || !distance.Clip {
+			t.Fatalf("unexpected normaliser %v", distance)
+		}
+		if got := distance.Mean; got != 0 {
+			t.Fatalf("expected mean 0, got %v", got)
+		}
+
+		if _, err := neuron.FitNormalisers(neuron.MinMax, false, append(samples, map[string]float64{"distance": 1})); err == nil {
+			t.Fatalf("expected error not raised")
+		}
+	})
+
+	t.Run("Compute", func(t *testing.T) {
+		layers := []neuron.Layer{{getGenes(t, 1000, -1000)}}
+		net, err := neuron.NewNeuralNet([]string{"distance", "height"}, []string{"jump"}, layers)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		input := map[string]float64{"distance": 10, "height": 246.128}
+		if got, _ := net.Compute(input); got["jump"] {
+			t.Fatalf("jump expected not to be trigged")
+		}
+
+		net, err = neuron.Configure(net, neuron.WithNormalisers(map[string]neuron.Normaliser{
+			"distance": {Method: neuron.MinMax, Min: 0, Max: 10},
+			"height":   {Method: neuron.MinMax, Min: 0, Max: 1000},
+		}))
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if got, _ := net.Compute(input); !got["jump"] {
+			t.Fatalf("jump expected to be trigged")
+		}
+		if !strings.Contains(net.String(), "NORMALISE distance: minmax [0, 10]\n") {
+			t.Fatalf("expected normaliser in %v", net.String())
+		}
+
+		var buf bytes.Buffer
+		if err := net.Save(&buf); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		loaded, err := neuron.LoadNet(&buf)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if got, ok := loaded.GetNormaliser("height"); !ok || got.Max != 1000 {
+			t.Fatalf("unexpected normaliser %v", got)
+		}
+		if got, _ := loaded.Compute(input); !got["jump"] {
+			t.Fatalf("jump expected to be trigged")
+		}
+	})
+
+	t.Run("WithNormalisers", func(t *testing.T) {
+		layers := []neuron.Layer{{getGenes(t, 1)}}
+		cases := []map[string]neuron.Normaliser{
+			{"unknown": {Method: neuron.MinMax, Min: 0, Max: 1}},
+			{"distance": {Method: neuron.MinMax, Min: 1, Max: 1}},
+			{"distance": {Method: neuron.Standard, Std: 0}},
+			{"distance": {Method: neuron.Standard, Mean: 10, Std: 2, Clip: true}},
+		}
+		for _, normalisers := range cases {
+			if _, err := neuron.NewNeuralNet([]string{"distance"}, []string{"jump"}, layers, neuron.WithNormalisers(normalisers)); err == nil {
+				t.Fatalf("%v: expected error not raised", normalisers)
+			}
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cacilhas/neuron/neuron"
+)
+
+func TestPipeline(t *testing.T) {
+	// flee when the enemy is stronger, attack otherwise
+	upper, err := neuron.NewNeuralNet(
+		[]string{"enemy", "strength"},
+		[]string{"attack", "flee", "shout"},
+		[]neuron.Layer{{getGenes(t, -1, 1), getGenes(t, 1, -1), getGenes(t, 0, 1)}},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	// run when fleeing, walk forward when attacking and not tired
+	lower, err := neuron.NewNeuralNet(
+		[]string{"fight", "run away", "tired"},
+		[]string{"run", "walk"},
+		[]neuron.Layer{{getGenes(t, 0, 1, 0), getGenes(t, 1, 0, -10)}},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	wiring := map[string]string{"attack": "fight", "flee": "run away"}
+	pipe, err := neuron.NewPipeline(upper, lower, wiring)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	t.Run("GetSensors", func(t *testing.T) {
+		expected := "enemy, strength, tired"
+		if got := strings.Join(pipe.GetSensors(), ", "); got != expected {
+			t.Fatalf("expected %v, got %v", expected, got)
+		}
+	})
+
+	t.Run("GetActions", func(t *testing.T) {
+		expected := "run, shout, walk"
+		if got := strings.Join(pipe.GetActions(), ", "); got != expected {
+			t.Fatalf("expected %v, got %v", expected, got)
+		}
+	})
+
+	t.Run("Compute", func(t *testing.T) {
+		cases := []struct {
+			input    map[string]float64
+			expected map[string]bool
+		}{
+			{map[string]float64{"enemy": 10, "strength": 2, "tired": 0}, map[string]bool{"run": true, "walk": false, "shout": true}},
+			{map[string]float64{"enemy": 2, "strength": 10, "tired": 0}, map[string]bool{"run": false, "walk": true, "shout": true}},
+			{map[string]float64{"enemy": 2, "strength": 10, "tired": 1}, map[string]bool{"run": false, "walk": false, "shout": true}},
+		}
+		for _, c := range cases {
+			got, err := pipe.Compute(c.input)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			for action, expected := range c.expected {
+				if got[action] != expected {
+					t.Fatalf("%v: expected %v, got %v", c.input, c.expected, got)
+				}
+			}
+		}
+		if _, err := pipe.Compute(map[string]float64{"enemy": 1, "strength": 1, "tired": 1, "fight": 1}); err == nil {
+			t.Fatalf("expected error not raised")
+		}
+	})
+
+	t.Run("NewPipeline", func(t *testing.T) {
+		cases := []map[string]string{
+			{"run": "fight"},
+			{"attack": "walk"},
+			{"attack": "fight", "flee": "fight"},
+			{},
+		}
+		for _, wiring := range cases {
+			if _, err := neuron.NewPipeline(upper, lower, wiring); err == nil {
+				t.Fatalf("%v: expected error not raised", wiring)
+			}
+		}
+	})
+
+	t.Run("GetUpperChild", func(t *testing.T) {
+		child := pipe.GetUpperChild(10)
+		if child.GetLower() != pipe.GetLower() {
+			t.Fatalf("lower net expected to be kept")
+		}
+		if child.GetUpper() == pipe.GetUpper() {
+			t.Fatalf("upper net expected to evolve")
+		}
+	})
+
+	t.Run("GetLowerChild", func(t *testing.T) {
+		child := pipe.GetLowerChild(10)
+		if child.GetUpper() != pipe.GetUpper() {
+			t.Fatalf("upper net expected to be kept")
+		}
+		if child.GetLower() == pipe.GetLower() {
+			t.Fatalf("lower net expected to evolve")
+		}
+	})
+}
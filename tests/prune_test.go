@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/cacilhas/neuron/neuron"
+)
+
+func TestPrune(t *testing.T) {
+	layers := []neuron.Layer{
+		{getGenes(t, 1, 1), getGenes(t, 0, 0), getGenes(t, -1, -1), getGenes(t, 2, 1)},
+		{getGenes(t, 1, 5, 5, 0), getGenes(t, 1, 1, 1, 0), getGenes(t, -1, -1, -1, -1)},
+		{getGenes(t, 1, 1, 3)},
+	}
+	net, err := neuron.NewNeuralNet([]string{"a", "b"}, []string{"x"}, layers)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	samples := []map[string]float64{
+		{"a": 1, "b": 2},
+		{"a": 3, "b": 0},
+		{"a": 0, "b": 0},
+	}
+
+	t.Run("static", func(t *testing.T) {
+		pruned, report, err := neuron.Prune(net, neuron.PruneOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		expected := []neuron.NeuronRef{{Layer: 0, Neuron: 1}, {Layer: 0, Neuron: 3}, {Layer: 1, Neuron: 2}}
+		if len(report.Removed) != len(expected) {
+			t.Fatalf("expected %v, got %v", expected, report.Removed)
+		}
+		for i, ref := range expected {
+			if report.Removed[i] != ref {
+				t.Fatalf("expected %v, got %v", expected, report.Removed)
+			}
+		}
+		if got := len(pruned.GetNeurons(0)); got != 2 {
+			t.Fatalf("expected 2 neurons, got %v", got)
+		}
+		if got := pruned.GetNeurons(1)[0].GetSize(); got != 2 {
+			t.Fatalf("expected size 2, got %v", got)
+		}
+		if report.SizeAfter >= report.SizeBefore {
+			t.Fatalf("expected %v to be smaller than %v", report.SizeAfter, report.SizeBefore)
+		}
+		if report.RemovedGenes != 13 {
+			t.Fatalf("expected 13 removed genes, got %v", report.RemovedGenes)
+		}
+	})
+
+	t.Run("samples", func(t *testing.T) {
+		pruned, report, err := neuron.Prune(net, neuron.PruneOptions{Samples: samples})
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if got := len(pruned.GetNeurons(0)); got != 1 {
+			t.Fatalf("expected 1 neuron, got %v", got)
+		}
+		if got := len(report.Removed); got != 4 {
+			t.Fatalf("expected 4 removed neurons, got %v", report.Removed)
+		}
+		for _, sample := range samples {
+			expected, _ := net.Compute(sample)
+			got, _ := pruned.Compute(sample)
+			if got["x"] != expected["x"] {
+				t.Fatalf("%v: expected %v, got %v", sample, expected, got)
+			}
+		}
+	})
+
+	t.Run("Constant", func(t *testing.T) {
+		constant := []map[string]float64{{"a": 1, "b": 2}, {"a": 2, "b": 1}}
+		_, report, err := neuron.Prune(net, neuron.PruneOptions{Samples: constant})
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if len(report.Constant) == 0 || report.Constant[0] != (neuron.NeuronRef{Layer: 0, Neuron: 0}) {
+			t.Fatalf("expected constant neuron 0, 0, got %v", report.Constant)
+		}
+	})
+
+	t.Run("Threshold", func(t *testing.T) {
+		_, report, err := neuron.Prune(net, neuron.PruneOptions{Threshold: 1})
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if report.ZeroedGenes != 15 {
+			t.Fatalf("expected 15 zeroed genes, got %v", report.ZeroedGenes)
+		}
+		if _, _, err := neuron.Prune(net, neuron.PruneOptions{Threshold: 1, Samples: samples}); err == nil {
+			t.Fatalf("expected error not raised")
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/cacilhas/neuron/neuron"
+)
+
+func TestRender(t *testing.T) {
+	layers := []neuron.Layer{
+		{getGenes(t, 1000, 0), getGenes(t, 0, -1000)},
+		{getGenes(t, 1000, 1000)},
+	}
+	net, err := neuron.NewNeuralNet([]string{"a & b", "y"}, []string{"<go>"}, layers, neuron.WithActivations(neuron.Linear, neuron.Step))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	at := func(t *testing.T, options neuron.RenderOptions, x, y int) color.NRGBA {
+		var buf bytes.Buffer
+		if err := neuron.RenderPNG(&buf, net, options); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		img, err := png.Decode(&buf)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if size := img.Bounds().Size(); size.X != 200 || size.Y != 150 {
+			t.Fatalf("expected 200×150, got %v×%v", size.X, size.Y)
+		}
+		return color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+	}
+	// Columns at x = 25, 100 and 175; sensors and hidden neurons at y = 50
+	// and 100, the action at y = 75
+	options := neuron.RenderOptions{Width: 200, Height: 150}
+
+	t.Run("RenderPNG", func(t *testing.T) {
+		if got := at(t, options, 62, 50); got.B < 0x80 || got.R > 0x80 {
+			t.Fatalf("expected a blue edge, got %v", got)
+		}
+		if got := at(t, options, 62, 100); got.R < 0x80 || got.B > 0x80 {
+			t.Fatalf("expected a red edge, got %v", got)
+		}
+		if got := at(t, options, 62, 75); got != (color.NRGBA{0xff, 0xff, 0xff, 0xff}) {
+			t.Fatalf("zero genes expected not to be drawn, got %v", got)
+		}
+		if got := at(t, options, 100, 50); got != (color.NRGBA{0xff, 0xff, 0xff, 0xff}) {
+			t.Fatalf("expected a white neuron, got %v", got)
+		}
+	})
+
+	t.Run("Input", func(t *testing.T) {
+		shaded := options
+		shaded.Input = map[string]float64{"a & b": 1, "y": 1}
+		if got := at(t, shaded, 100, 50); got.B < 0x80 || got.R > 0x80 {
+			t.Fatalf("expected a blue neuron, got %v", got)
+		}
+		if got := at(t, shaded, 100, 100); got.R < 0x80 || got.B > 0x80 {
+			t.Fatalf("expected a red neuron, got %v", got)
+		}
+
+		shaded.Input = map[string]float64{"a & b": 1}
+		if err := neuron.RenderPNG(ioutil.Discard, net, shaded); err == nil {
+			t.Fatalf("expected error not raised")
+		}
+	})
+
+	t.Run("RenderSVG", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := neuron.RenderSVG(&buf, net, neuron.RenderOptions{Input: map[string]float64{"a & b": 2, "y": 1}}); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		var doc struct {
+			Width  string `xml:"width,attr"`
+			Groups []struct {
+				Lines []struct {
+					Title string `xml:"title"`
+				} `xml:"line"`
+				Circles []struct {
+					Stroke string `xml:"stroke,attr"`
+				} `xml:"circle"`
+				Texts []string `xml:"text"`
+			} `xml:"g"`
+		}
+		if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+			t.Fatalf("unexpected error %v in\n%v", err, buf.String())
+		}
+		if doc.Width != "800" || len(doc.Groups) != 2 {
+			t.Fatalf("unexpected document\n%v", buf.String())
+		}
+		var titles []string
+		for _, line := range doc.Groups[0].Lines {
+			titles = append(titles, line.Title)
+		}
+		if got := strings.Join(titles, " "); got != "1000 -1000 1000 1000" {
+			t.Fatalf("expected genes 1000 -1000 1000 1000, got %v", got)
+		}
+		if got := strings.Join(doc.Groups[1].Texts, ", "); got != "a & b, y, <go>" {
+			t.Fatalf("expected labels a & b, y, <go>, got %v", got)
+		}
+		circles := doc.Groups[1].Circles
+		if len(circles) != 5 || circles[4].Stroke != "#2ca02c" {
+			t.Fatalf("expected the fired action ringed, got %v", circles)
+		}
+	})
+
+	t.Run("tall layer", func(t *testing.T) {
+		tall, err := neuron.BuildNet(neuron.NewTopology([]string{"x"}, []string{"a"}, 400))
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		var buf bytes.Buffer
+		if err := neuron.RenderSVG(&buf, tall, neuron.RenderOptions{}); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		var doc struct {
+			Circles []struct {
+				R float64 `xml:"r,attr"`
+			} `xml:"g>circle"`
+		}
+		if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if len(doc.Circles) != 402 {
+			t.Fatalf("expected 402 circles, got %v", len(doc.Circles))
+		}
+		for _, circle := range doc.Circles {
+			if circle.R <= 0 {
+				t.Fatalf("expected a positive radius, got %v", circle.R)
+			}
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/cacilhas/neuron/neuron"
+)
+
+func TestSchema(t *testing.T) {
+	zero := 0.0
+	schema := neuron.Schema{
+		"distance": {Unit: "m", Description: "distance to the obstacle", Min: -100, Max: 100},
+		"height":   {Unit: "px", Min: 0, Max: 500, Default: &zero},
+		"jump":     {Description: "jump over the obstacle"},
+	}
+	layers := []neuron.Layer{{getGenes(t, -1, 1)}}
+	net, err := neuron.NewNeuralNet([]string{"distance", "height"}, []string{"jump"}, layers, neuron.WithSchema(schema))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	t.Run("Compute", func(t *testing.T) {
+		got, err := net.Compute(map[string]float64{"distance": -12, "height": 246.128})
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if !got["jump"] {
+			t.Fatalf("jump expected to be trigged")
+		}
+
+		got, err = net.Compute(map[string]float64{"distance": -12})
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if !got["jump"] {
+			t.Fatalf("jump expected to be trigged")
+		}
+	})
+
+	t.Run("checkInput", func(t *testing.T) {
+		cases := []map[string]float64{
+			{"distance": -120, "height": 246.128},
+			{"height": 246.128},
+			{"distance": 1, "height": 1, "width": 1},
+		}
+		for _, input := range cases {
+			if _, err := net.Compute(input); err == nil {
+				t.Fatalf("%v: expected error not raised", input)
+			}
+		}
+	})
+
+	t.Run("String", func(t *testing.T) {
+		expected := "FIELD distance: unit \"m\" range [-100, 100] doc \"distance to the obstacle\"\n" +
+			"FIELD height: unit \"px\" range [0, 500] default 0\n" +
+			"FIELD jump: doc \"jump over the obstacle\"\n"
+		if got := net.String(); !strings.Contains(got, expected) {
+			t.Fatalf("expected\n%v\nin\n%v", expected, got)
+		}
+	})
+
+	t.Run("LoadNet", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := net.Save(&buf); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		loaded, err := neuron.LoadNet(&buf)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if got, expected := loaded.String(), net.String(); got != expected {
+			t.Fatalf("expected\n%v\ngot\n%v", expected, got)
+		}
+		if got := loaded.GetSchema()["height"].Default; got == nil || *got != 0 {
+			t.Fatalf("expected default 0, got %v", got)
+		}
+	})
+
+	t.Run("WithSchema", func(t *testing.T) {
+		cases := []neuron.Schema{
+			{"width": {}},
+			{"distance": {Min: 1, Max: 0}},
+			{"jump": {Default: &zero}},
+			{"distance": {Min: 1, Max: 2, Default: &zero}},
+		}
+		for _, schema := range cases {
+			if _, err := neuron.NewNeuralNet([]string{"distance"}, []string{"jump"}, []neuron.Layer{{getGenes(t, 1)}}, neuron.WithSchema(schema)); err == nil {
+				t.Fatalf("%v: expected error not raised", schema)
+			}
+		}
+	})
+}
@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/cacilhas/neuron/neuron"
+)
+
+func TestSurgery(t *testing.T) {
+	layers := []neuron.Layer{
+		{getGenes(t, 1, 2), getGenes(t, 3, 4)},
+		{getGenes(t, 5, 6), getGenes(t, -7, 8)},
+	}
+	original, err := neuron.NewNeuralNet(
+		[]string{"b", "d"},
+		[]string{"x", "z"},
+		layers,
+		neuron.WithActivations(neuron.ReLU, neuron.Linear),
+		neuron.WithSchema(neuron.Schema{"d": {Unit: "m"}}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// Loaded nets must support surgery as well
+	var buf bytes.Buffer
+	if err := original.Save(&buf); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	net, err := neuron.LoadNet(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	t.Run("AddSensor", func(t *testing.T) {
+		got, err := neuron.AddSensor(net, "c")
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if sensors := strings.Join(got.GetSensors(), ", "); sensors != "b, c, d" {
+			t.Fatalf("expected b, c, d, got %v", sensors)
+		}
+		if front := got.GetNeurons(0)[1]; front.GetGene(0) != 3 || front.GetGene(1) != 0 || front.GetGene(2) != 4 {
+			t.Fatalf("unexpected neuron %v", front)
+		}
+		if got.GetActivation(1) != neuron.Linear {
+			t.Fatalf("activations not preserved")
+		}
+		for _, c := range []float64{-3, 0, 12} {
+			before, _ := net.Compute(map[string]float64{"b": 1, "d": 2})
+			after, _ := got.Compute(map[string]float64{"b": 1, "c": c, "d": 2})
+			if before["x"] != after["x"] || before["z"] != after["z"] {
+				t.Fatalf("expected %v, got %v", before, after)
+			}
+		}
+		if _, err := neuron.AddSensor(net, "b"); err == nil {
+			t.Fatalf("expected error not raised")
+		}
+
+		bounded, err := neuron.Configure(net, neuron.WithBounds(neuron.Bounds{Min: 1, Max: 100}, neuron.DefaultBounds))
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if _, err := neuron.AddSensor(bounded, "c"); err == nil {
+			t.Fatalf("expected error not raised for bounds excluding 0")
+		}
+	})
+
+	t.Run("RemoveSensor", func(t *testing.T) {
+		got, err := neuron.RemoveSensor(net, "d")
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if sensors := strings.Join(got.GetSensors(), ", "); sensors != "b" {
+			t.Fatalf("expected b, got %v", sensors)
+		}
+		if front := got.GetNeurons(0)[1]; front.GetSize() != 1 || front.GetGene(0) != 3 {
+			t.Fatalf("unexpected neuron %v", front)
+		}
+		if _, ok := got.GetSchema()["d"]; ok {
+			t.Fatalf("schema of removed sensor kept")
+		}
+		if _, err := neuron.RemoveSensor(got, "b"); err == nil {
+			t.Fatalf("expected error not raised")
+		}
+		if _, err := neuron.RemoveSensor(net, "c"); err == nil {
+			t.Fatalf("expected error not raised")
+		}
+	})
+
+	t.Run("AddAction", func(t *testing.T) {
+		got, err := neuron.AddAction(net, "y", neuron.Constant(9))
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if actions := strings.Join(got.GetActions(), ", "); actions != "x, y, z" {
+			t.Fatalf("expected x, y, z, got %v", actions)
+		}
+		back := got.GetNeurons(1)
+		if back[0].GetGene(0) != 5 || back[1].GetGene(0) != 9 || back[2].GetGene(0) != -7 {
+			t.Fatalf("unexpected neurons %v", back)
+		}
+		if got.GetNeurons(0)[0].GetGene(1) != 2 {
+			t.Fatalf("front neurons not preserved")
+		}
+		if _, err := neuron.AddAction(net, "x", nil); err == nil {
+			t.Fatalf("expected error not raised")
+		}
+	})
+
+	t.Run("RemoveAction", func(t *testing.T) {
+		got, err := neuron.RemoveAction(net, "x")
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if actions := strings.Join(got.GetActions(), ", "); actions != "z" {
+			t.Fatalf("expected z, got %v", actions)
+		}
+		if back := got.GetNeurons(1); len(back) != 1 || back[0].GetGene(0) != -7 {
+			t.Fatalf("unexpected neurons %v", back)
+		}
+		if _, err := neuron.RemoveAction(got, "z"); err == nil {
+			t.Fatalf("expected error not raised")
+		}
+	})
+
+	t.Run("original untouched", func(t *testing.T) {
+		if got := net.String(); got != original.String() {
+			t.Fatalf("expected\n%v\ngot\n%v", original, got)
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/cacilhas/neuron/neuron"
+)
+
+func TestParseNet(t *testing.T) {
+	t.Run("hand written", func(t *testing.T) {
+		text := `# steering
+SENSORS: distance, "speed, km/h"
+ACTIONS: brake
+ACTIVATIONS: linear, step
+NEURONS:
+1 0
+0 -1
+
+1000 1000
+
+-----
+`
+		net, err := neuron.ParseNet(text)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if got := strings.Join(net.GetSensors(), "|"); got != "distance|speed, km/h" {
+			t.Fatalf("expected distance|speed, km/h, got %v", got)
+		}
+		if got := net.GetNeurons(0)[1].GetGene(1); got != -1 {
+			t.Fatalf("expected -1, got %v", got)
+		}
+		if got := net.GetActivation(1); got != neuron.Step {
+			t.Fatalf("expected step, got %v", got)
+		}
+		if got := net.String(); got != strings.TrimPrefix(text, "# steering\n") {
+			t.Fatalf("expected\n%v\ngot\n%v", text, got)
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(0))
+		one := 1.0
+		bounds := neuron.WithBounds(neuron.Bounds{Min: -2000, Max: 2000, Mode: neuron.Reflect})
+		net, err := neuron.BuildNet(neuron.Topology{
+			Sensors: []string{"x", "y: metres", " padded "},
+			Actions: []string{"left", "a -> b"},
+			Hidden:  []neuron.LayerSpec{{Width: 3, Activation: neuron.Tanh}},
+			Rand:    rng,
+		},
+			bounds,
+			neuron.WithNormalisers(map[string]neuron.Normaliser{
+				"x":         {Method: neuron.Standard, Mean: 2.5, Std: 0.1, Min: -1, Max: 1, Clip: true},
+				"y: metres": {Method: neuron.MinMax, Min: 0, Max: 10},
+			}),
+			neuron.WithSchema(neuron.Schema{
+				" padded ": {Unit: "m/s", Description: "quoted \"doc\"", Min: 0, Max: 10, Default: &one},
+				"left":     {Description: "turn"},
+			}),
+			neuron.WithFrozenNeurons(neuron.NeuronRef{Layer: 0, Neuron: 2}, neuron.NeuronRef{Layer: 1, Neuron: 0}),
+		)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		other, _ := neuron.BuildNet(neuron.Topology{
+			Sensors: []string{"z"},
+			Actions: []string{"jump"},
+			Hidden:  []neuron.LayerSpec{{Width: 1, Activation: neuron.Tanh}},
+			Rand:    rng,
+		}, bounds)
+		merged, err := neuron.Merge(net, other)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		for _, current := range []neuron.NeuralNet{net, merged} {
+			text := current.String()
+			parsed, err := neuron.ParseNet(text)
+			if err != nil {
+				t.Fatalf("unexpected error %v in\n%v", err, text)
+			}
+			if got := parsed.String(); got != text {
+				t.Fatalf("expected\n%v\ngot\n%v", text, got)
+			}
+		}
+		if !strings.Contains(net.String(), "\nBOUNDS 1: [-2000, 2000] reflect\n") {
+			t.Fatalf("bounds missing from\n%v", net)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		cases := map[string]string{
+			"SENSORS: x\nACTIONS: y\nNEURONS:\n1\n":                                "line 5: missing -----",
+			"SENSORS: x\nACTIONS: y\nNEURONS:\n1 a\n-----\n":                       "line 4: gene 1",
+			"SENSORS: x\nACTIONS: y\nNEURONS:\n4294967296\n-----\n":                "line 4: gene 0",
+			"SENSORS: x\nSENSORS: y\n":                                             "line 2: repeated SENSORS",
+			"SENSORS: x\nACTIONS: y\nCOLOUR: red\n":                                `line 3: unknown statement "COLOUR"`,
+			"SENSORS: x\nACTIONS: y\nBOUNDS 0: [1, 0] clamp\nNEURONS:\n1\n-----\n": "invalid bounds",
+			"SENSORS: x\nACTIONS: y\nFIELD x: unit m\n":                            "line 3: expected a quoted string",
+			"SENSORS: x\nACTIONS: y\nNEURONS:\n1 2\n-----\n":                       "expected size 1, got 2",
+			"SENSORS: x\nACTIONS: y\nNEURONS:\n1\n-----\nmore\n":                   "line 6: unexpected text after the net",
+			"SENSORS: x\nACTIONS: y\n":                                             "missing NEURONS",
+		}
+		for text, message := range cases {
+			_, err := neuron.ParseNet(text)
+			if err == nil || !strings.Contains(err.Error(), message) {
+				t.Fatalf("%q: expected %q, got %v", text, message, err)
+			}
+		}
+		_, err := neuron.ParseNet("SENSORS: x\nACTIONS: y\nBOUNDS 0: [0, 1] clamp\nNEURONS:\n5\n-----\n")
+		var rangeErr *neuron.GeneRangeError
+		if !errors.As(err, &rangeErr) {
+			t.Fatalf("expected *neuron.GeneRangeError, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/cacilhas/neuron/neuron"
+)
+
+func TestTransfer(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	net, err := neuron.BuildNet(neuron.Topology{
+		Sensors: []string{"x", "y"},
+		Actions: []string{"left", "right"},
+		Hidden:  []neuron.LayerSpec{{Width: 4}, {Width: 3, Activation: neuron.Tanh}},
+		Rand:    rng,
+	}, neuron.WithBounds(neuron.Bounds{Min: -2000, Max: 2000}))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	t.Run("Extract", func(t *testing.T) {
+		trunk, err := neuron.Extract(net, 2)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if len(trunk.Layers) != 2 || len(trunk.Layers[1]) != 3 {
+			t.Fatalf("unexpected trunk shape %v", trunk.Layers)
+		}
+		if trunk.Activations[1] != neuron.Tanh {
+			t.Fatalf("expected tanh, got %v", trunk.Activations[1])
+		}
+		if got := trunk.Layers[0][0].String(); got != net.GetNeurons(0)[0].String() {
+			t.Fatalf("expected %v, got %v", net.GetNeurons(0)[0], got)
+		}
+		for _, count := range []int{0, 4} {
+			if _, err := neuron.Extract(net, count); err == nil {
+				t.Fatalf("%v: expected error not raised", count)
+			}
+		}
+	})
+
+	trunk, _ := neuron.Extract(net, 2)
+	attached, err := neuron.Attach(trunk, neuron.Topology{
+		Actions: []string{"jump"},
+		Hidden:  []neuron.LayerSpec{{Width: 2}},
+		Rand:    rng,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	t.Run("Attach", func(t *testing.T) {
+		if got := strings.Join(attached.GetSensors(), ", "); got != "x, y" {
+			t.Fatalf("expected x, y, got %v", got)
+		}
+		if got := strings.Join(attached.GetActions(), ", "); got != "jump" {
+			t.Fatalf("expected jump, got %v", got)
+		}
+		if got := len(attached.GetNeurons(2)); got != 2 {
+			t.Fatalf("expected 2 head neurons, got %v", got)
+		}
+		if got := attached.GetBounds(3); got != net.GetBounds(1) {
+			t.Fatalf("expected %v, got %v", net.GetBounds(1), got)
+		}
+		for i := 0; i < 2; i++ {
+			for j := range attached.GetNeurons(i) {
+				if !attached.IsFrozen(i, j) {
+					t.Fatalf("neuron %v of layer %v expected frozen", j, i)
+				}
+			}
+		}
+		if attached.IsFrozen(2, 0) || attached.IsFrozen(9, 9) {
+			t.Fatalf("unexpected frozen neuron")
+		}
+	})
+
+	t.Run("GetChild", func(t *testing.T) {
+		child := attached.GetChild(200)
+		for i := 0; i < 2; i++ {
+			for j, neu := range child.GetNeurons(i) {
+				if neu.String() != attached.GetNeurons(i)[j].String() {
+					t.Fatalf("frozen neuron %v of layer %v changed", j, i)
+				}
+				if !child.IsFrozen(i, j) {
+					t.Fatalf("neuron %v of layer %v expected frozen", j, i)
+				}
+			}
+		}
+		if child.GetNeurons(2)[0].String() == attached.GetNeurons(2)[0].String() {
+			t.Fatalf("head expected to evolve")
+		}
+	})
+
+	t.Run("WithFrozenNeurons", func(t *testing.T) {
+		frozen, err := neuron.Configure(net, neuron.WithFrozenNeurons(neuron.NeuronRef{Layer: 2, Neuron: 1}))
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		child := frozen.GetChild(200)
+		if child.GetNeurons(2)[1].String() != net.GetNeurons(2)[1].String() {
+			t.Fatalf("frozen neuron changed")
+		}
+		if child.GetNeurons(2)[0].String() == net.GetNeurons(2)[0].String() {
+			t.Fatalf("unfrozen neuron expected to evolve")
+		}
+		if !strings.Contains(frozen.String(), "\nFROZEN 2: 1\n") {
+			t.Fatalf("frozen neurons missing from\n%v", frozen)
+		}
+		if _, err := neuron.Configure(net, neuron.WithFrozenNeurons(neuron.NeuronRef{Layer: 2, Neuron: 2})); err == nil {
+			t.Fatalf("expected error not raised")
+		}
+		if _, err := neuron.Configure(net, neuron.WithFrozenLayers(3)); err == nil {
+			t.Fatalf("expected error not raised")
+		}
+	})
+
+	t.Run("LoadNet", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := attached.Save(&buf); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		loaded, err := neuron.LoadNet(&buf)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if got := loaded.String(); got != attached.String() {
+			t.Fatalf("expected\n%v\ngot\n%v", attached, got)
+		}
+		if !loaded.IsFrozen(1, 2) || loaded.IsFrozen(2, 1) {
+			t.Fatalf("frozen flags not preserved")
+		}
+	})
+
+	t.Run("surgery", func(t *testing.T) {
+		added, err := neuron.AddAction(attached, "crouch", nil)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if !added.IsFrozen(0, 0) || added.IsFrozen(3, 0) {
+			t.Fatalf("frozen flags not carried over")
+		}
+	})
+}